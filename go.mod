@@ -3,6 +3,10 @@ module github.com/crisp-coder/gator
 go 1.24.6
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.36.0
+	golang.org/x/term v0.30.0
 )
+
+require golang.org/x/sys v0.31.0 // indirect
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+type Feed struct {
+	ID                  uuid.UUID  `json:"id"`
+	Name                string     `json:"name"`
+	URL                 string     `json:"url"`
+	Username            *string    `json:"username"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	LastError           *string    `json:"last_error"`
+	LastSuccessAt       *time.Time `json:"last_success_at"`
+	Paused              bool       `json:"paused"`
+}
+
+func (srv *Server) handleListFeeds(w http.ResponseWriter, r *http.Request) {
+	feeds, err := srv.db.ListFeeds(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving feeds", err)
+		return
+	}
+
+	res := []Feed{}
+	for _, feed := range feeds {
+		res = append(res, Feed{
+			ID:                  feed.ID,
+			Name:                feed.Name,
+			URL:                 feed.Url,
+			Username:            nullString(feed.Username),
+			ConsecutiveFailures: feed.ConsecutiveFailures,
+			LastError:           nullString(feed.LastError),
+			LastSuccessAt:       nullTime(feed.LastSuccessAt),
+			Paused:              feed.Paused,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+// handleCreateFeed adds a feed and follows it for the user, like addfeed.
+func (srv *Server) handleCreateFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if params.Name == "" || params.URL == "" {
+		respondWithError(w, http.StatusBadRequest, "missing name or url", nil)
+		return
+	}
+
+	feed, err := srv.db.CreateFeed(
+		r.Context(),
+		database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      params.Name,
+			Url:       params.URL,
+			UserID:    user.ID,
+		})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "feed already exists", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating feed", err)
+		return
+	}
+
+	_, err = srv.db.CreateFeedFollow(
+		r.Context(),
+		database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+		})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error following feed", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Feed{
+		ID:       feed.ID,
+		Name:     feed.Name,
+		URL:      feed.Url,
+		Username: &user.Name,
+	})
+}
+
+// handleDeleteFeed deletes a feed added by the user, with its posts. Feeds
+// other users follow, or with starred posts, are left in place.
+func (srv *Server) handleDeleteFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed_id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid feed id", err)
+		return
+	}
+
+	feed, err := srv.db.GetFeedByID(r.Context(), feed_id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving feed", err)
+		return
+	}
+	if err != nil || feed.UserID != user.ID {
+		respondWithError(w, http.StatusNotFound, "feed not found", nil)
+		return
+	}
+
+	deleted, err := srv.db.DeleteFeed(
+		r.Context(),
+		database.DeleteFeedParams{
+			ID:     feed_id,
+			UserID: user.ID,
+		})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error deleting feed", err)
+		return
+	}
+	if deleted == 0 {
+		respondWithError(w, http.StatusConflict, "feed is followed by other users or has starred posts", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+func TestCreateFeed(t *testing.T) {
+	ts, _ := newTestServer(t)
+	token := createTestUser(t, ts, "alice", "")
+
+	for _, body := range []any{"{", map[string]string{"name": "blog"}, map[string]string{"url": "https://example.com/feed"}} {
+		res := doRequest(t, ts, "POST", "/api/feeds", token, body)
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("POST /api/feeds %v: got status %v, want 400", body, res.StatusCode)
+		}
+	}
+
+	feed := createTestFeed(t, ts, token, "blog", "https://example.com/feed")
+	if feed.Name != "blog" || feed.Username == nil || *feed.Username != "alice" {
+		t.Errorf("unexpected feed: %+v", feed)
+	}
+
+	res := doRequest(t, ts, "POST", "/api/feeds", token, map[string]string{"name": "again", "url": "https://example.com/feed"})
+	expectStatus(t, res, http.StatusConflict)
+
+	// Adding a feed follows it
+	res = doRequest(t, ts, "GET", "/api/follows", token, nil)
+	expectStatus(t, res, http.StatusOK)
+	follows := []Follow{}
+	decodeBody(t, res, &follows)
+	if len(follows) != 1 || follows[0].FeedID != feed.ID {
+		t.Errorf("unexpected follows: %+v", follows)
+	}
+
+	res = doRequest(t, ts, "GET", "/api/feeds", "", nil)
+	expectStatus(t, res, http.StatusOK)
+	feeds := []Feed{}
+	decodeBody(t, res, &feeds)
+	if len(feeds) != 1 || feeds[0].ID != feed.ID {
+		t.Errorf("unexpected feeds: %+v", feeds)
+	}
+}
+
+func TestDeleteFeed(t *testing.T) {
+	ts, queries := newTestServer(t)
+	alice := createTestUser(t, ts, "alice", "")
+	bob := createTestUser(t, ts, "bob", "")
+	feed := createTestFeed(t, ts, alice, "blog", "https://example.com/feed")
+	post := createTestPost(t, queries, feed.ID, "first post", time.Now())
+
+	res := doRequest(t, ts, "DELETE", "/api/feeds/not-an-id", alice, nil)
+	expectStatus(t, res, http.StatusBadRequest)
+
+	res = doRequest(t, ts, "DELETE", "/api/feeds/"+uuid.NewString(), alice, nil)
+	expectStatus(t, res, http.StatusNotFound)
+
+	// Only the user that added the feed may delete it
+	res = doRequest(t, ts, "DELETE", "/api/feeds/"+feed.ID.String(), bob, nil)
+	expectStatus(t, res, http.StatusNotFound)
+
+	// Not while another user follows it
+	res = doRequest(t, ts, "POST", "/api/follows", bob, map[string]string{"url": feed.URL})
+	expectStatus(t, res, http.StatusCreated)
+	res = doRequest(t, ts, "DELETE", "/api/feeds/"+feed.ID.String(), alice, nil)
+	expectStatus(t, res, http.StatusConflict)
+
+	// Nor while its posts are starred, even after the follow is gone
+	bob_user, err := queries.GetUserByName(t.Context(), "bob")
+	if err != nil {
+		t.Fatalf("error retrieving user: %v", err)
+	}
+	err = queries.StarPost(
+		t.Context(),
+		database.StarPostParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    bob_user.ID,
+			PostID:    post.ID,
+		})
+	if err != nil {
+		t.Fatalf("error starring post: %v", err)
+	}
+	res = doRequest(t, ts, "DELETE", "/api/follows/"+feed.ID.String(), bob, nil)
+	expectStatus(t, res, http.StatusNoContent)
+	res = doRequest(t, ts, "DELETE", "/api/feeds/"+feed.ID.String(), alice, nil)
+	expectStatus(t, res, http.StatusConflict)
+
+	err = queries.UnstarPost(
+		t.Context(),
+		database.UnstarPostParams{
+			UserID: bob_user.ID,
+			PostID: post.ID,
+		})
+	if err != nil {
+		t.Fatalf("error unstarring post: %v", err)
+	}
+	res = doRequest(t, ts, "DELETE", "/api/feeds/"+feed.ID.String(), alice, nil)
+	expectStatus(t, res, http.StatusNoContent)
+
+	_, err = queries.GetFeedByID(t.Context(), feed.ID)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("feed still exists after delete: %v", err)
+	}
+
+	res = doRequest(t, ts, "DELETE", "/api/feeds/"+feed.ID.String(), alice, nil)
+	expectStatus(t, res, http.StatusNotFound)
+}
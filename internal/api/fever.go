@@ -0,0 +1,406 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/auth"
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// feverItemsPerPage is the number of items Fever returns per request.
+const feverItemsPerPage = 50
+
+// errBadFeverRequest wraps errors caused by the client's parameters, other
+// errors are server errors.
+var errBadFeverRequest = errors.New("bad request")
+
+// respondWithFeverError responds to an error from feverItems or feverMark.
+func respondWithFeverError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBadFeverRequest) {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	respondWithError(w, http.StatusInternalServerError, "error handling fever request", err)
+}
+
+// feverGroupID returns the Fever group id of a follow category. Fever needs
+// integer ids and categories are only names, so the id is a hash of the name.
+func feverGroupID(category string) int64 {
+	return int64(crc32.ChecksumIEEE([]byte(category)) & 0x7fffffff)
+}
+
+// joinIDs returns ids as the comma separated string Fever uses for id lists.
+func joinIDs(ids []int64) string {
+	strs := []string{}
+	for _, id := range ids {
+		strs = append(strs, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(strs, ",")
+}
+
+// handleFever serves the Fever api. Every request is a POST to /fever/?api
+// with the api_key form field, and the query string names what to return,
+// e.g. ?api&items&since_id=10. Each named part is added to one response.
+func (srv *Server) handleFever(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid form", err)
+		return
+	}
+
+	res := map[string]any{
+		"api_version": 3,
+		"auth":        0,
+	}
+
+	api_key := strings.ToLower(r.PostForm.Get("api_key"))
+	user, err := srv.db.GetUserByFeverAPIKey(r.Context(), sql.NullString{String: auth.HashToken(api_key), Valid: api_key != ""})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Fever reports failed logins in the body
+		respondWithJSON(w, http.StatusOK, res)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user", err)
+		return
+	}
+	res["auth"] = 1
+
+	feeds, err := srv.db.GetFeverFeedsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving feeds", err)
+		return
+	}
+	last_refreshed := time.Time{}
+	for _, feed := range feeds {
+		if feed.LastFetchedAt.Valid && feed.LastFetchedAt.Time.After(last_refreshed) {
+			last_refreshed = feed.LastFetchedAt.Time
+		}
+	}
+	res["last_refreshed_on_time"] = max(last_refreshed.Unix(), 0)
+
+	// Marks are applied first so the rest of the response reflects them
+	query := r.URL.Query()
+	if r.Form.Has("mark") {
+		err = srv.feverMark(r, user, feeds)
+		if err != nil {
+			respondWithFeverError(w, err)
+			return
+		}
+	}
+
+	if query.Has("groups") {
+		res["groups"], res["feeds_groups"] = feverGroups(feeds)
+	}
+	if query.Has("feeds") {
+		res["feeds"] = feverFeeds(feeds)
+		_, res["feeds_groups"] = feverGroups(feeds)
+	}
+	if query.Has("favicons") {
+		// Feeds have no stored icons
+		res["favicons"] = []any{}
+	}
+	if query.Has("links") {
+		res["links"] = []any{}
+	}
+	if query.Has("items") {
+		res["items"], res["total_items"], err = srv.feverItems(r, user)
+		if err != nil {
+			respondWithFeverError(w, err)
+			return
+		}
+	}
+	if query.Has("unread_item_ids") {
+		ids, err := srv.db.GetUnreadSerialIDsForUser(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error retrieving unread items", err)
+			return
+		}
+		res["unread_item_ids"] = joinIDs(ids)
+	}
+	if query.Has("saved_item_ids") {
+		ids, err := srv.db.GetStarredSerialIDsForUser(r.Context(), user.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error retrieving saved items", err)
+			return
+		}
+		res["saved_item_ids"] = joinIDs(ids)
+	}
+
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+type feverGroup struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feverFeedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+// feverGroups returns the follow categories as groups, and the feeds in
+// each group.
+func feverGroups(feeds []database.GetFeverFeedsForUserRow) ([]feverGroup, []feverFeedsGroup) {
+	feed_ids := map[string][]int64{}
+	for _, feed := range feeds {
+		if feed.Category.Valid {
+			feed_ids[feed.Category.String] = append(feed_ids[feed.Category.String], feed.SerialID)
+		}
+	}
+
+	categories := []string{}
+	for category := range feed_ids {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	groups := []feverGroup{}
+	feeds_groups := []feverFeedsGroup{}
+	for _, category := range categories {
+		groups = append(groups, feverGroup{ID: feverGroupID(category), Title: category})
+		feeds_groups = append(feeds_groups, feverFeedsGroup{
+			GroupID: feverGroupID(category),
+			FeedIDs: joinIDs(feed_ids[category]),
+		})
+	}
+	return groups, feeds_groups
+}
+
+type feverFeed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+func feverFeeds(feeds []database.GetFeverFeedsForUserRow) []feverFeed {
+	res := []feverFeed{}
+	for _, feed := range feeds {
+		last_updated := int64(0)
+		if feed.LastFetchedAt.Valid {
+			last_updated = feed.LastFetchedAt.Time.Unix()
+		}
+		res = append(res, feverFeed{
+			ID:                feed.SerialID,
+			Title:             feed.Name,
+			URL:               feed.Url,
+			SiteURL:           feed.Url,
+			LastUpdatedOnTime: last_updated,
+		})
+	}
+	return res
+}
+
+type feverItem struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// feverItems returns a page of items selected by since_id, max_id or
+// with_ids, and the total number of items the user can see.
+func (srv *Server) feverItems(r *http.Request, user database.User) ([]feverItem, int64, error) {
+	query := r.URL.Query()
+	params := database.GetFeverItemsParams{
+		UserID:   user.ID,
+		MaxItems: feverItemsPerPage,
+	}
+
+	if value := query.Get("since_id"); value != "" {
+		since_id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: invalid since_id", errBadFeverRequest)
+		}
+		params.SinceID = sql.NullInt64{Int64: since_id, Valid: true}
+	}
+	if query.Has("max_id") {
+		params.NewestFirst = true
+		// max_id=0 asks for the newest items
+		max_id, err := strconv.ParseInt(query.Get("max_id"), 10, 64)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: invalid max_id", errBadFeverRequest)
+		}
+		if max_id > 0 {
+			params.MaxID = sql.NullInt64{Int64: max_id, Valid: true}
+		}
+	}
+	if value := query.Get("with_ids"); value != "" {
+		params.WithIds = []int64{}
+		for _, id := range strings.Split(value, ",") {
+			serial_id, err := strconv.ParseInt(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%w: invalid with_ids", errBadFeverRequest)
+			}
+			params.WithIds = append(params.WithIds, serial_id)
+		}
+	}
+
+	posts, err := srv.db.GetFeverItems(r.Context(), params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error retrieving items: %w", err)
+	}
+	total, err := srv.db.CountFeverItems(r.Context(), user.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error counting items: %w", err)
+	}
+
+	items := []feverItem{}
+	for _, post := range posts {
+		item := feverItem{
+			ID:            post.SerialID,
+			FeedID:        post.FeedSerialID,
+			Title:         post.Title,
+			HTML:          post.Description.String,
+			URL:           post.Url,
+			CreatedOnTime: post.PublishedAt.Unix(),
+		}
+		if post.IsStarred {
+			item.IsSaved = 1
+		}
+		if post.IsRead {
+			item.IsRead = 1
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+// feverMark applies mark=item with as=read, unread, saved or unsaved, and
+// mark=feed or mark=group with as=read and a before time. Group 0 is all
+// of the user's feeds.
+func (srv *Server) feverMark(r *http.Request, user database.User, feeds []database.GetFeverFeedsForUserRow) error {
+	mark := r.Form.Get("mark")
+	as := r.Form.Get("as")
+	id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid id", errBadFeverRequest)
+	}
+
+	if mark == "item" {
+		return srv.feverMarkItem(r, user, id, as)
+	}
+
+	if as != "read" {
+		return fmt.Errorf("%w: unsupported mark %v as %v", errBadFeverRequest, mark, as)
+	}
+	before, err := strconv.ParseInt(r.Form.Get("before"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid before", errBadFeverRequest)
+	}
+	params := database.MarkFollowedPostsReadBeforeParams{
+		Now:    time.Now(),
+		UserID: user.ID,
+		Before: time.Unix(before, 0),
+	}
+
+	switch mark {
+	case "feed":
+		params.FeedSerialID = sql.NullInt64{Int64: id, Valid: true}
+	case "group":
+		if id != 0 {
+			category, ok := feverGroupCategory(feeds, id)
+			if !ok {
+				return fmt.Errorf("%w: unknown group %v", errBadFeverRequest, id)
+			}
+			params.Category = sql.NullString{String: category, Valid: true}
+		}
+	default:
+		return fmt.Errorf("%w: unsupported mark %v", errBadFeverRequest, mark)
+	}
+
+	err = srv.db.MarkFollowedPostsReadBefore(r.Context(), params)
+	if err != nil {
+		return fmt.Errorf("error marking posts read: %w", err)
+	}
+	return nil
+}
+
+// feverGroupCategory finds the follow category with a group id.
+func feverGroupCategory(feeds []database.GetFeverFeedsForUserRow, group_id int64) (string, bool) {
+	for _, feed := range feeds {
+		if feed.Category.Valid && feverGroupID(feed.Category.String) == group_id {
+			return feed.Category.String, true
+		}
+	}
+	return "", false
+}
+
+func (srv *Server) feverMarkItem(r *http.Request, user database.User, serial_id int64, as string) error {
+	posts, err := srv.db.GetReaderItemsByIDs(
+		r.Context(),
+		database.GetReaderItemsByIDsParams{
+			UserID:    user.ID,
+			SerialIds: []int64{serial_id},
+		})
+	if err != nil {
+		return fmt.Errorf("error retrieving item: %w", err)
+	}
+	if len(posts) == 0 {
+		return fmt.Errorf("%w: unknown item %v", errBadFeverRequest, serial_id)
+	}
+	post_id := posts[0].ID
+
+	now := time.Now()
+	switch as {
+	case "read":
+		err = srv.db.MarkPostRead(
+			r.Context(),
+			database.MarkPostReadParams{
+				ID:        uuid.New(),
+				CreatedAt: now,
+				UpdatedAt: now,
+				UserID:    user.ID,
+				PostID:    post_id,
+			})
+	case "unread":
+		err = srv.db.MarkPostUnread(
+			r.Context(),
+			database.MarkPostUnreadParams{
+				UserID: user.ID,
+				PostID: post_id,
+			})
+	case "saved":
+		err = srv.db.StarPost(
+			r.Context(),
+			database.StarPostParams{
+				ID:        uuid.New(),
+				CreatedAt: now,
+				UpdatedAt: now,
+				UserID:    user.ID,
+				PostID:    post_id,
+			})
+	case "unsaved":
+		err = srv.db.UnstarPost(
+			r.Context(),
+			database.UnstarPostParams{
+				UserID: user.ID,
+				PostID: post_id,
+			})
+	default:
+		return fmt.Errorf("%w: unsupported mark item as %v", errBadFeverRequest, as)
+	}
+	if err != nil {
+		return fmt.Errorf("error marking item: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+type Follow struct {
+	FeedID   uuid.UUID `json:"feed_id"`
+	Feedname string    `json:"feed_name"`
+	URL      string    `json:"url"`
+	Category *string   `json:"category"`
+}
+
+func (srv *Server) handleListFollows(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := srv.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving follows", err)
+		return
+	}
+
+	res := []Follow{}
+	for _, follow := range follows {
+		res = append(res, Follow{
+			FeedID:   follow.FeedID,
+			Feedname: follow.Feedname,
+			URL:      follow.Url,
+			Category: nullString(follow.Category),
+		})
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+func (srv *Server) handleCreateFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	type parameters struct {
+		URL      string `json:"url"`
+		Category string `json:"category"`
+	}
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	feed, err := srv.db.GetFeedByURL(r.Context(), params.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "feed not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving feed", err)
+		return
+	}
+
+	follow, err := srv.db.CreateFeedFollow(
+		r.Context(),
+		database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+			Category:  sql.NullString{String: params.Category, Valid: params.Category != ""},
+		})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "already following feed", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error following feed", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, Follow{
+		FeedID:   follow.FeedID,
+		Feedname: follow.Feedname,
+		URL:      feed.Url,
+		Category: nullString(follow.Category),
+	})
+}
+
+func (srv *Server) handleDeleteFollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feed_id, err := uuid.Parse(r.PathValue("feed_id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid feed id", err)
+		return
+	}
+
+	err = srv.db.DeleteFeedFollow(
+		r.Context(),
+		database.DeleteFeedFollowParams{
+			UserID: user.ID,
+			FeedID: feed_id,
+		})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error unfollowing feed", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFollows(t *testing.T) {
+	ts, _ := newTestServer(t)
+	alice := createTestUser(t, ts, "alice", "")
+	bob := createTestUser(t, ts, "bob", "")
+	feed := createTestFeed(t, ts, alice, "blog", "https://example.com/feed")
+
+	res := doRequest(t, ts, "POST", "/api/follows", bob, `{"url": "https://example.com/feed", "folder": "news"}`)
+	expectStatus(t, res, http.StatusBadRequest)
+
+	res = doRequest(t, ts, "POST", "/api/follows", bob, map[string]string{"url": "https://example.com/missing"})
+	expectStatus(t, res, http.StatusNotFound)
+
+	res = doRequest(t, ts, "POST", "/api/follows", bob, map[string]string{"url": feed.URL, "category": "news"})
+	expectStatus(t, res, http.StatusCreated)
+	follow := Follow{}
+	decodeBody(t, res, &follow)
+	if follow.FeedID != feed.ID || follow.Category == nil || *follow.Category != "news" {
+		t.Errorf("unexpected follow: %+v", follow)
+	}
+
+	res = doRequest(t, ts, "POST", "/api/follows", bob, map[string]string{"url": feed.URL})
+	expectStatus(t, res, http.StatusConflict)
+
+	res = doRequest(t, ts, "GET", "/api/follows", bob, nil)
+	expectStatus(t, res, http.StatusOK)
+	follows := []Follow{}
+	decodeBody(t, res, &follows)
+	if len(follows) != 1 || follows[0].URL != feed.URL {
+		t.Errorf("unexpected follows: %+v", follows)
+	}
+
+	res = doRequest(t, ts, "DELETE", "/api/follows/not-an-id", bob, nil)
+	expectStatus(t, res, http.StatusBadRequest)
+
+	res = doRequest(t, ts, "DELETE", "/api/follows/"+feed.ID.String(), bob, nil)
+	expectStatus(t, res, http.StatusNoContent)
+
+	res = doRequest(t, ts, "GET", "/api/follows", bob, nil)
+	expectStatus(t, res, http.StatusOK)
+	follows = []Follow{}
+	decodeBody(t, res, &follows)
+	if len(follows) != 0 {
+		t.Errorf("follows left after unfollow: %+v", follows)
+	}
+}
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/auth"
+	"github.com/crisp-coder/gator/internal/database"
+)
+
+// The Google Reader api is served under /greader, the url to give clients
+// such as Reeder or NetNewsWire that support FreshRSS style servers.
+const greaderPrefix = "/greader"
+
+// Stream and tag ids of the Google Reader api. Labels are the categories of
+// the user's follows.
+const (
+	readingListStream = "user/-/state/com.google/reading-list"
+	starredStream     = "user/-/state/com.google/starred"
+	readStream        = "user/-/state/com.google/read"
+	keptUnreadStream  = "user/-/state/com.google/kept-unread"
+	labelStreamPrefix = "user/-/label/"
+	feedStreamPrefix  = "feed/"
+)
+
+func (srv *Server) registerGReader(mux *http.ServeMux) {
+	mux.HandleFunc("GET "+greaderPrefix+"/accounts/ClientLogin", srv.handleGReaderLogin)
+	mux.HandleFunc("POST "+greaderPrefix+"/accounts/ClientLogin", srv.handleGReaderLogin)
+
+	api := greaderPrefix + "/reader/api/0"
+	mux.HandleFunc("GET "+api+"/token", srv.greaderAuthenticated(srv.handleGReaderToken))
+	mux.HandleFunc("GET "+api+"/user-info", srv.greaderAuthenticated(srv.handleGReaderUserInfo))
+	mux.HandleFunc("GET "+api+"/subscription/list", srv.greaderAuthenticated(srv.handleGReaderSubscriptions))
+	mux.HandleFunc("GET "+api+"/tag/list", srv.greaderAuthenticated(srv.handleGReaderTags))
+	mux.HandleFunc("GET "+api+"/unread-count", srv.greaderAuthenticated(srv.handleGReaderUnreadCount))
+	mux.HandleFunc("GET "+api+"/stream/contents", srv.greaderAuthenticated(srv.handleGReaderStreamContents))
+	mux.HandleFunc("GET "+api+"/stream/contents/{stream...}", srv.greaderAuthenticated(srv.handleGReaderStreamContents))
+	mux.HandleFunc("GET "+api+"/stream/items/ids", srv.greaderAuthenticated(srv.handleGReaderItemIDs))
+	mux.HandleFunc("GET "+api+"/stream/items/contents", srv.greaderAuthenticated(srv.handleGReaderItemContents))
+	mux.HandleFunc("POST "+api+"/stream/items/contents", srv.greaderAuthenticated(srv.handleGReaderItemContents))
+	mux.HandleFunc("POST "+api+"/edit-tag", srv.greaderAuthenticated(srv.handleGReaderEditTag))
+}
+
+// greaderAuthenticated passes the user to handler. Google Reader clients send
+// the token from ClientLogin as "Authorization: GoogleLogin auth=<token>".
+func (srv *Server) greaderAuthenticated(handler func(http.ResponseWriter, *http.Request, database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		token, ok := strings.CutPrefix(strings.TrimSpace(token), "auth=")
+		if !strings.EqualFold(scheme, "GoogleLogin") || !ok || token == "" {
+			respondWithText(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		user, err := srv.userForToken(r.Context(), token)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithText(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error retrieving user", err)
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
+
+func respondWithText(w http.ResponseWriter, code int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write([]byte(text))
+}
+
+// handleGReaderLogin exchanges the username (Email) and password (Passwd)
+// for a new api token, returned as the Auth value.
+func (srv *Server) handleGReaderLogin(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithText(w, http.StatusBadRequest, "Error=BadRequest\n")
+		return
+	}
+
+	user, err := srv.db.GetUserByName(r.Context(), r.Form.Get("Email"))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user", err)
+		return
+	}
+	if err != nil || !user.HashedPassword.Valid ||
+		auth.CheckPasswordHash(r.Form.Get("Passwd"), user.HashedPassword.String) != nil {
+		respondWithText(w, http.StatusUnauthorized, "Error=BadAuthentication\n")
+		return
+	}
+
+	token, err := auth.CreateToken(r.Context(), srv.db, user.ID, "greader")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating token", err)
+		return
+	}
+
+	respondWithText(w, http.StatusOK, fmt.Sprintf("SID=%v\nLSID=%v\nAuth=%v\n", token, token, token))
+}
+
+// handleGReaderToken returns the token clients send as T with edits. Edits
+// are authenticated by the Authorization header, which browsers never send
+// on their own, so T is not checked.
+func (srv *Server) handleGReaderToken(w http.ResponseWriter, r *http.Request, user database.User) {
+	respondWithText(w, http.StatusOK, user.ID.String())
+}
+
+func (srv *Server) handleGReaderUserInfo(w http.ResponseWriter, r *http.Request, user database.User) {
+	type response struct {
+		UserID        string `json:"userId"`
+		UserName      string `json:"userName"`
+		UserProfileID string `json:"userProfileId"`
+		UserEmail     string `json:"userEmail"`
+	}
+	respondWithJSON(w, http.StatusOK, response{
+		UserID:        user.ID.String(),
+		UserName:      user.Name,
+		UserProfileID: user.ID.String(),
+	})
+}
+
+type greaderCategory struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+func (srv *Server) handleGReaderSubscriptions(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := srv.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving follows", err)
+		return
+	}
+
+	type subscription struct {
+		ID         string            `json:"id"`
+		Title      string            `json:"title"`
+		Categories []greaderCategory `json:"categories"`
+		URL        string            `json:"url"`
+		HTMLURL    string            `json:"htmlUrl"`
+		IconURL    string            `json:"iconUrl"`
+	}
+	type response struct {
+		Subscriptions []subscription `json:"subscriptions"`
+	}
+	res := response{Subscriptions: []subscription{}}
+	for _, follow := range follows {
+		categories := []greaderCategory{}
+		if follow.Category.Valid {
+			categories = append(categories, greaderCategory{
+				ID:    labelStreamPrefix + follow.Category.String,
+				Label: follow.Category.String,
+			})
+		}
+		res.Subscriptions = append(res.Subscriptions, subscription{
+			ID:         feedStreamPrefix + follow.Url,
+			Title:      follow.Feedname,
+			Categories: categories,
+			URL:        follow.Url,
+			HTMLURL:    follow.Url,
+		})
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+func (srv *Server) handleGReaderTags(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := srv.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving follows", err)
+		return
+	}
+
+	type tag struct {
+		ID   string `json:"id"`
+		Type string `json:"type,omitempty"`
+	}
+	type response struct {
+		Tags []tag `json:"tags"`
+	}
+	res := response{Tags: []tag{{ID: starredStream}}}
+
+	labels := map[string]bool{}
+	for _, follow := range follows {
+		if follow.Category.Valid {
+			labels[follow.Category.String] = true
+		}
+	}
+	sorted := []string{}
+	for label := range labels {
+		sorted = append(sorted, label)
+	}
+	sort.Strings(sorted)
+	for _, label := range sorted {
+		res.Tags = append(res.Tags, tag{ID: labelStreamPrefix + label, Type: "folder"})
+	}
+
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+// handleGReaderUnreadCount counts unread posts per feed, per label and for
+// the whole reading list.
+func (srv *Server) handleGReaderUnreadCount(w http.ResponseWriter, r *http.Request, user database.User) {
+	counts, err := srv.db.CountUnreadPostsByFeed(r.Context(), user.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error counting unread posts", err)
+		return
+	}
+
+	type total struct {
+		count  int64
+		newest time.Time
+	}
+	totals := map[string]*total{}
+	order := []string{}
+	add := func(id string, row database.CountUnreadPostsByFeedRow) {
+		t, ok := totals[id]
+		if !ok {
+			t = &total{}
+			totals[id] = t
+			order = append(order, id)
+		}
+		t.count += row.Unread
+		if row.NewestPublishedAt.After(t.newest) {
+			t.newest = row.NewestPublishedAt
+		}
+	}
+	for _, row := range counts {
+		add(readingListStream, row)
+		add(feedStreamPrefix+row.FeedUrl, row)
+		if row.Category.Valid {
+			add(labelStreamPrefix+row.Category.String, row)
+		}
+	}
+
+	type unreadCount struct {
+		ID                      string `json:"id"`
+		Count                   int64  `json:"count"`
+		NewestItemTimestampUsec string `json:"newestItemTimestampUsec"`
+	}
+	type response struct {
+		Max          int           `json:"max"`
+		UnreadCounts []unreadCount `json:"unreadcounts"`
+	}
+	res := response{Max: maxStreamItems, UnreadCounts: []unreadCount{}}
+	for _, id := range order {
+		res.UnreadCounts = append(res.UnreadCounts, unreadCount{
+			ID:                      id,
+			Count:                   totals[id].count,
+			NewestItemTimestampUsec: fmt.Sprint(totals[id].newest.UnixMicro()),
+		})
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,443 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// defaultStreamItems is the page size when a client does not send n.
+	defaultStreamItems = 20
+	// maxStreamItems caps n, clients syncing item ids ask for thousands.
+	maxStreamItems = 10000
+)
+
+// itemIDPrefix starts the long form of an item id. The short form is the
+// same number in decimal. Both are the post's serial_id.
+const itemIDPrefix = "tag:google.com,2005:reader/item/"
+
+func longItemID(serial_id int64) string {
+	return fmt.Sprintf("%v%016x", itemIDPrefix, uint64(serial_id))
+}
+
+func parseItemID(id string) (int64, error) {
+	if hex, ok := strings.CutPrefix(id, itemIDPrefix); ok {
+		serial_id, err := strconv.ParseUint(hex, 16, 64)
+		return int64(serial_id), err
+	}
+	return strconv.ParseInt(id, 10, 64)
+}
+
+// normalizeStreamID replaces the user id in a user/<id>/... stream id with
+// the "-" that stands for the current user.
+func normalizeStreamID(stream string) string {
+	rest, ok := strings.CutPrefix(stream, "user/")
+	if !ok {
+		return stream
+	}
+	_, rest, ok = strings.Cut(rest, "/")
+	if !ok {
+		return stream
+	}
+	return "user/-/" + rest
+}
+
+// streamParams builds the query for the items of a stream from the Google
+// Reader parameters: s or the path for the stream, xt and it to exclude or
+// include read or starred items, ot and nt for the time range in unix
+// seconds, r=o for oldest first, n for the count and c for the
+// continuation returned with the previous page.
+func streamParams(r *http.Request, user database.User) (database.GetReaderItemsParams, error) {
+	query := r.URL.Query()
+	params := database.GetReaderItemsParams{
+		UserID:   user.ID,
+		MaxItems: defaultStreamItems,
+	}
+
+	stream := r.PathValue("stream")
+	if stream == "" {
+		stream = query.Get("s")
+	}
+	if stream == "" {
+		stream = readingListStream
+	}
+	err := applyStream(&params, normalizeStreamID(stream))
+	if err != nil {
+		return params, err
+	}
+
+	for _, exclude := range query["xt"] {
+		if normalizeStreamID(exclude) == readStream {
+			params.ExcludeRead = true
+		}
+	}
+	for _, include := range query["it"] {
+		err = applyStream(&params, normalizeStreamID(include))
+		if err != nil {
+			return params, err
+		}
+	}
+
+	if value := query.Get("ot"); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid ot: %w", err)
+		}
+		params.NewerThan = sql.NullTime{Time: time.Unix(seconds, 0).UTC(), Valid: true}
+	}
+	if value := query.Get("nt"); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return params, fmt.Errorf("invalid nt: %w", err)
+		}
+		params.OlderThan = sql.NullTime{Time: time.Unix(seconds, 0).UTC(), Valid: true}
+	}
+	params.OldestFirst = query.Get("r") == "o"
+
+	if value := query.Get("n"); value != "" {
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count < 1 {
+			return params, errors.New("invalid n")
+		}
+		params.MaxItems = min(count, maxStreamItems)
+	}
+	if value := query.Get("c"); value != "" {
+		published_at, serial_id, err := decodeContinuation(value)
+		if err != nil {
+			return params, err
+		}
+		params.AfterPublishedAt = sql.NullTime{Time: published_at, Valid: true}
+		params.AfterSerialID = sql.NullInt64{Int64: serial_id, Valid: true}
+	}
+
+	return params, nil
+}
+
+// applyStream narrows params to the items of a stream id.
+func applyStream(params *database.GetReaderItemsParams, stream string) error {
+	switch {
+	case stream == readingListStream:
+	case stream == starredStream:
+		params.StarredOnly = true
+	case stream == readStream:
+		params.ReadOnly = true
+	case strings.HasPrefix(stream, labelStreamPrefix):
+		label := strings.TrimPrefix(stream, labelStreamPrefix)
+		params.Category = sql.NullString{String: label, Valid: true}
+	case strings.HasPrefix(stream, feedStreamPrefix):
+		feed_url := strings.TrimPrefix(stream, feedStreamPrefix)
+		params.FeedUrl = sql.NullString{String: feed_url, Valid: true}
+	default:
+		return fmt.Errorf("unsupported stream: %v", stream)
+	}
+	return nil
+}
+
+// continuation returns the c value for the page after posts, empty after
+// the last page. It marks the (published_at, serial_id) of the last post, so
+// posts arriving between pages do not shift the pages.
+func continuation(params database.GetReaderItemsParams, posts []database.GetReaderItemsRow) string {
+	if int64(len(posts)) < params.MaxItems {
+		return ""
+	}
+	last := posts[len(posts)-1]
+	raw := fmt.Sprintf("%d:%d", last.PublishedAt.UnixMicro(), last.SerialID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeContinuation(value string) (time.Time, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid continuation")
+	}
+	micros, serial, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return time.Time{}, 0, errors.New("invalid continuation")
+	}
+	published_at, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid continuation")
+	}
+	serial_id, err := strconv.ParseInt(serial, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid continuation")
+	}
+	return time.UnixMicro(published_at).UTC(), serial_id, nil
+}
+
+type greaderLink struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type greaderItem struct {
+	ID            string        `json:"id"`
+	CrawlTimeMsec string        `json:"crawlTimeMsec"`
+	TimestampUsec string        `json:"timestampUsec"`
+	Published     int64         `json:"published"`
+	Updated       int64         `json:"updated"`
+	Title         string        `json:"title"`
+	Canonical     []greaderLink `json:"canonical"`
+	Alternate     []greaderLink `json:"alternate"`
+	Summary       struct {
+		Direction string `json:"direction"`
+		Content   string `json:"content"`
+	} `json:"summary"`
+	Categories []string `json:"categories"`
+	Origin     struct {
+		StreamID string `json:"streamId"`
+		Title    string `json:"title"`
+		HTMLURL  string `json:"htmlUrl"`
+	} `json:"origin"`
+}
+
+func greaderItemFromDB(post database.GetReaderItemsRow) greaderItem {
+	item := greaderItem{
+		ID:            longItemID(post.SerialID),
+		CrawlTimeMsec: strconv.FormatInt(post.CreatedAt.UnixMilli(), 10),
+		TimestampUsec: strconv.FormatInt(post.PublishedAt.UnixMicro(), 10),
+		Published:     post.PublishedAt.Unix(),
+		Updated:       post.UpdatedAt.Unix(),
+		Title:         post.Title,
+		Canonical:     []greaderLink{{Href: post.Url}},
+		Alternate:     []greaderLink{{Href: post.Url, Type: "text/html"}},
+		Categories:    []string{readingListStream},
+	}
+	item.Summary.Direction = "ltr"
+	item.Summary.Content = post.Description.String
+	item.Origin.StreamID = feedStreamPrefix + post.FeedUrl
+	item.Origin.Title = post.Feedname
+	item.Origin.HTMLURL = post.FeedUrl
+
+	if post.IsRead {
+		item.Categories = append(item.Categories, readStream)
+	}
+	if post.IsStarred {
+		item.Categories = append(item.Categories, starredStream)
+	}
+	if post.Category.Valid {
+		item.Categories = append(item.Categories, labelStreamPrefix+post.Category.String)
+	}
+	return item
+}
+
+func respondWithItems(w http.ResponseWriter, stream string, posts []database.GetReaderItemsRow, next string) {
+	type response struct {
+		ID           string        `json:"id"`
+		Updated      int64         `json:"updated"`
+		Items        []greaderItem `json:"items"`
+		Continuation string        `json:"continuation,omitempty"`
+	}
+	res := response{
+		ID:           stream,
+		Updated:      time.Now().Unix(),
+		Items:        []greaderItem{},
+		Continuation: next,
+	}
+	for _, post := range posts {
+		res.Items = append(res.Items, greaderItemFromDB(post))
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+func (srv *Server) handleGReaderStreamContents(w http.ResponseWriter, r *http.Request, user database.User) {
+	params, err := streamParams(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	posts, err := srv.db.GetReaderItems(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving items", err)
+		return
+	}
+
+	stream := r.PathValue("stream")
+	if stream == "" {
+		stream = r.URL.Query().Get("s")
+	}
+	respondWithItems(w, stream, posts, continuation(params, posts))
+}
+
+// handleGReaderItemIDs lists the short ids of the items in a stream, which
+// clients use to sync read and starred state before fetching contents.
+func (srv *Server) handleGReaderItemIDs(w http.ResponseWriter, r *http.Request, user database.User) {
+	params, err := streamParams(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	posts, err := srv.db.GetReaderItems(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving items", err)
+		return
+	}
+
+	type itemRef struct {
+		ID              string `json:"id"`
+		TimestampUsec   string `json:"timestampUsec"`
+		DirectStreamIDs []any  `json:"directStreamIds"`
+	}
+	type response struct {
+		ItemRefs     []itemRef `json:"itemRefs"`
+		Continuation string    `json:"continuation,omitempty"`
+	}
+	res := response{ItemRefs: []itemRef{}, Continuation: continuation(params, posts)}
+	for _, post := range posts {
+		res.ItemRefs = append(res.ItemRefs, itemRef{
+			ID:              strconv.FormatInt(post.SerialID, 10),
+			TimestampUsec:   strconv.FormatInt(post.PublishedAt.UnixMicro(), 10),
+			DirectStreamIDs: []any{},
+		})
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+// itemsFromForm returns the posts for the item ids sent as i parameters.
+func (srv *Server) itemsFromForm(r *http.Request, user database.User) ([]database.GetReaderItemsRow, error) {
+	serial_ids := []int64{}
+	for _, id := range r.Form["i"] {
+		serial_id, err := parseItemID(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid item id: %v", id)
+		}
+		serial_ids = append(serial_ids, serial_id)
+	}
+
+	rows, err := srv.db.GetReaderItemsByIDs(
+		r.Context(),
+		database.GetReaderItemsByIDsParams{
+			UserID:    user.ID,
+			SerialIds: serial_ids,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	posts := []database.GetReaderItemsRow{}
+	for _, row := range rows {
+		posts = append(posts, database.GetReaderItemsRow(row))
+	}
+	return posts, nil
+}
+
+func (srv *Server) handleGReaderItemContents(w http.ResponseWriter, r *http.Request, user database.User) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid form", err)
+		return
+	}
+
+	posts, err := srv.itemsFromForm(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	respondWithItems(w, readingListStream, posts, "")
+}
+
+// handleGReaderEditTag adds (a) and removes (r) the read and starred tags
+// of the items (i). Other tags are ignored.
+func (srv *Server) handleGReaderEditTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid form", err)
+		return
+	}
+
+	posts, err := srv.itemsFromForm(r, user)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	add := []string{}
+	for _, tag := range r.Form["a"] {
+		add = append(add, normalizeStreamID(tag))
+	}
+	remove := []string{}
+	for _, tag := range r.Form["r"] {
+		remove = append(remove, normalizeStreamID(tag))
+	}
+
+	for _, post := range posts {
+		err := srv.editTags(r, user, post.ID, add, remove)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error editing tags", err)
+			return
+		}
+	}
+
+	respondWithText(w, http.StatusOK, "OK")
+}
+
+func (srv *Server) editTags(r *http.Request, user database.User, post_id uuid.UUID, add, remove []string) error {
+	now := time.Now()
+
+	if slices.Contains(add, readStream) {
+		err := srv.db.MarkPostRead(
+			r.Context(),
+			database.MarkPostReadParams{
+				ID:        uuid.New(),
+				CreatedAt: now,
+				UpdatedAt: now,
+				UserID:    user.ID,
+				PostID:    post_id,
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if slices.Contains(remove, readStream) || slices.Contains(add, keptUnreadStream) {
+		err := srv.db.MarkPostUnread(
+			r.Context(),
+			database.MarkPostUnreadParams{
+				UserID: user.ID,
+				PostID: post_id,
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	if slices.Contains(add, starredStream) {
+		err := srv.db.StarPost(
+			r.Context(),
+			database.StarPostParams{
+				ID:        uuid.New(),
+				CreatedAt: now,
+				UpdatedAt: now,
+				UserID:    user.ID,
+				PostID:    post_id,
+			})
+		if err != nil {
+			return err
+		}
+	}
+	if slices.Contains(remove, starredStream) {
+		err := srv.db.UnstarPost(
+			r.Context(),
+			database.UnstarPostParams{
+				UserID: user.ID,
+				PostID: post_id,
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/cursor"
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/crisp-coder/gator/internal/dates"
+	"github.com/google/uuid"
+)
+
+type Post struct {
+	ID          uuid.UUID `json:"id"`
+	FeedID      uuid.UUID `json:"feed_id"`
+	Feedname    string    `json:"feed_name"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	PublishedAt time.Time `json:"published_at"`
+	Read        bool      `json:"read"`
+	Starred     bool      `json:"starred"`
+}
+
+// defaultPageSize is the number of posts returned when limit is not given.
+const defaultPageSize = 20
+
+// handleBrowsePosts lists posts from the user's follows with the same
+// filters as the browse command, given as query parameters: limit, all,
+// feed, since (a duration), before (a date), grep and after (the next
+// cursor of the previous page). Unlike browse, listed posts are not marked
+// read.
+func (srv *Server) handleBrowsePosts(w http.ResponseWriter, r *http.Request, user database.User) {
+	query := r.URL.Query()
+
+	params := database.GetPostsForUserParams{
+		UserID:      user.ID,
+		IncludeRead: query.Get("all") == "true",
+		Feed:        sql.NullString{String: query.Get("feed"), Valid: query.Get("feed") != ""},
+		Grep:        sql.NullString{String: query.Get("grep"), Valid: query.Get("grep") != ""},
+		MaxPosts:    defaultPageSize,
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || limit < 1 {
+			respondWithError(w, http.StatusBadRequest, "invalid limit", nil)
+			return
+		}
+		params.MaxPosts = limit
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.ParseDuration(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid since duration", nil)
+			return
+		}
+		params.Since = sql.NullTime{Time: time.Now().Add(-since), Valid: true}
+	}
+	if value := query.Get("before"); value != "" {
+		before, err := dates.Parse(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid before date", nil)
+			return
+		}
+		params.Before = sql.NullTime{Time: before, Valid: true}
+	}
+	if value := query.Get("after"); value != "" {
+		c, err := cursor.Decode(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "invalid cursor", nil)
+			return
+		}
+		params.AfterPublishedAt = sql.NullTime{Time: c.PublishedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+
+	posts, err := srv.db.GetPostsForUser(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving posts", err)
+		return
+	}
+
+	type response struct {
+		Posts []Post `json:"posts"`
+		// Next is the cursor for the following page, empty after the last page
+		Next string `json:"next,omitempty"`
+	}
+	res := response{Posts: []Post{}}
+	for _, post := range posts {
+		res.Posts = append(res.Posts, Post{
+			ID:          post.ID,
+			FeedID:      post.FeedID,
+			Feedname:    post.Feedname,
+			Title:       post.Title,
+			URL:         post.Url,
+			Description: post.Description.String,
+			PublishedAt: post.PublishedAt,
+			Read:        post.IsRead,
+			Starred:     post.IsStarred,
+		})
+	}
+
+	// A full page means there may be more posts
+	if len(posts) > 0 && int64(len(posts)) == params.MaxPosts {
+		last := posts[len(posts)-1]
+		res.Next = cursor.Cursor{PublishedAt: last.PublishedAt, ID: last.ID}.Encode()
+	}
+
+	respondWithJSON(w, http.StatusOK, res)
+}
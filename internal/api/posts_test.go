@@ -0,0 +1,67 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBrowsePosts(t *testing.T) {
+	ts, queries := newTestServer(t)
+	alice := createTestUser(t, ts, "alice", "")
+	bob := createTestUser(t, ts, "bob", "")
+	feed := createTestFeed(t, ts, alice, "blog", "https://example.com/feed")
+
+	now := time.Now().Truncate(time.Second)
+	createTestPost(t, queries, feed.ID, "oldest", now.Add(-3*time.Hour))
+	createTestPost(t, queries, feed.ID, "middle", now.Add(-2*time.Hour))
+	createTestPost(t, queries, feed.ID, "newest", now.Add(-time.Hour))
+
+	for _, query := range []string{"limit=0", "limit=x", "since=yesterday", "before=soon", "after=not-a-cursor"} {
+		res := doRequest(t, ts, "GET", "/api/posts?"+query, alice, nil)
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("GET /api/posts?%v: got status %v, want 400", query, res.StatusCode)
+		}
+	}
+
+	type page struct {
+		Posts []Post `json:"posts"`
+		Next  string `json:"next"`
+	}
+
+	res := doRequest(t, ts, "GET", "/api/posts?limit=2", alice, nil)
+	expectStatus(t, res, http.StatusOK)
+	first := page{}
+	decodeBody(t, res, &first)
+	if len(first.Posts) != 2 || first.Posts[0].Title != "newest" || first.Posts[1].Title != "middle" {
+		t.Fatalf("unexpected first page: %+v", first.Posts)
+	}
+	if first.Next == "" {
+		t.Fatalf("missing next cursor after a full page")
+	}
+
+	res = doRequest(t, ts, "GET", "/api/posts?limit=2&after="+first.Next, alice, nil)
+	expectStatus(t, res, http.StatusOK)
+	second := page{}
+	decodeBody(t, res, &second)
+	if len(second.Posts) != 1 || second.Posts[0].Title != "oldest" || second.Next != "" {
+		t.Fatalf("unexpected second page: %+v", second)
+	}
+
+	res = doRequest(t, ts, "GET", "/api/posts?since=90m", alice, nil)
+	expectStatus(t, res, http.StatusOK)
+	recent := page{}
+	decodeBody(t, res, &recent)
+	if len(recent.Posts) != 1 || recent.Posts[0].Title != "newest" {
+		t.Errorf("unexpected posts since 90m: %+v", recent.Posts)
+	}
+
+	// Posts only come from followed feeds
+	res = doRequest(t, ts, "GET", "/api/posts", bob, nil)
+	expectStatus(t, res, http.StatusOK)
+	unfollowed := page{}
+	decodeBody(t, res, &unfollowed)
+	if len(unfollowed.Posts) != 0 {
+		t.Errorf("got posts from unfollowed feeds: %+v", unfollowed.Posts)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/auth"
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/lib/pq"
+)
+
+// Server serves the gator JSON api over the same queries as the cli.
+type Server struct {
+	db *database.Queries
+}
+
+func NewServer(db *database.Queries) *Server {
+	return &Server{db: db}
+}
+
+// Handler returns the routes of the api.
+func (srv *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /api/users", srv.handleListUsers)
+	mux.HandleFunc("POST /api/users", srv.handleCreateUser)
+	mux.HandleFunc("POST /api/login", srv.handleLogin)
+
+	mux.HandleFunc("GET /api/feeds", srv.handleListFeeds)
+	mux.HandleFunc("POST /api/feeds", srv.authenticated(srv.handleCreateFeed))
+	mux.HandleFunc("DELETE /api/feeds/{id}", srv.authenticated(srv.handleDeleteFeed))
+
+	mux.HandleFunc("GET /api/follows", srv.authenticated(srv.handleListFollows))
+	mux.HandleFunc("POST /api/follows", srv.authenticated(srv.handleCreateFollow))
+	mux.HandleFunc("DELETE /api/follows/{feed_id}", srv.authenticated(srv.handleDeleteFollow))
+
+	mux.HandleFunc("GET /api/posts", srv.authenticated(srv.handleBrowsePosts))
+
+	srv.registerGReader(mux)
+
+	// Fever clients post to the directory url with the query naming the call
+	mux.HandleFunc("/fever/", srv.handleFever)
+
+	return mux
+}
+
+// authenticated passes the user making the request to handler. The user is
+// found from the api token in the Authorization: Bearer header.
+func (srv *Server) authenticated(handler func(http.ResponseWriter, *http.Request, database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.GetBearerToken(r.Header)
+		if err != nil {
+			respondWithError(w, http.StatusUnauthorized, err.Error(), nil)
+			return
+		}
+
+		user, err := srv.userForToken(r.Context(), token)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusUnauthorized, "invalid token", nil)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error retrieving user", err)
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
+
+// userForToken returns the user owning an api token, or sql.ErrNoRows.
+func (srv *Server) userForToken(ctx context.Context, token string) (database.User, error) {
+	return srv.db.GetUserByAPIToken(
+		ctx,
+		database.GetUserByAPITokenParams{
+			Now:       time.Now(),
+			TokenHash: auth.HashToken(token),
+		})
+}
+
+// isUniqueViolation reports whether err is a postgres unique constraint error.
+func isUniqueViolation(err error) bool {
+	pq_err := &pq.Error{}
+	return errors.As(err, &pq_err) && pq_err.Code == "23505"
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/crisp-coder/gator/internal/dbtest"
+	"github.com/google/uuid"
+)
+
+// newTestServer serves the api over a freshly migrated test database.
+func newTestServer(t *testing.T) (*httptest.Server, *database.Queries) {
+	t.Helper()
+	queries := database.New(dbtest.Open(t, "gator_test_api"))
+	ts := httptest.NewServer(NewServer(queries).Handler())
+	t.Cleanup(ts.Close)
+	return ts, queries
+}
+
+// newOfflineServer serves the api over a database that cannot be reached,
+// for requests that are rejected before any query runs.
+func newOfflineServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	db, err := sql.Open("postgres", "postgres://localhost:1/gator?sslmode=disable&connect_timeout=1")
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ts := httptest.NewServer(NewServer(database.New(db)).Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// doRequest sends a request with an optional bearer token. A string body is
+// sent as is, any other body as json.
+func doRequest(t *testing.T, ts *httptest.Server, method, path, token string, body any) *http.Response {
+	t.Helper()
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("error marshalling body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, ts.URL+path, reader)
+	if err != nil {
+		t.Fatalf("error building request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("error sending request: %v", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+func expectStatus(t *testing.T, res *http.Response, code int) {
+	t.Helper()
+	if res.StatusCode != code {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("%v %v: got status %v, want %v: %s",
+			res.Request.Method, res.Request.URL.Path, res.StatusCode, code, body)
+	}
+}
+
+func decodeBody(t *testing.T, res *http.Response, v any) {
+	t.Helper()
+	err := json.NewDecoder(res.Body).Decode(v)
+	if err != nil {
+		t.Fatalf("error decoding response: %v", err)
+	}
+}
+
+// createTestUser registers a user through the api and returns its token.
+func createTestUser(t *testing.T, ts *httptest.Server, name, password string) string {
+	t.Helper()
+	res := doRequest(t, ts, "POST", "/api/users", "", map[string]string{"name": name, "password": password})
+	expectStatus(t, res, http.StatusCreated)
+
+	body := struct {
+		Name  string `json:"name"`
+		Token string `json:"token"`
+	}{}
+	decodeBody(t, res, &body)
+	if body.Name != name || body.Token == "" {
+		t.Fatalf("unexpected user response: %+v", body)
+	}
+	return body.Token
+}
+
+// createTestFeed adds a feed through the api, which follows it for the user.
+func createTestFeed(t *testing.T, ts *httptest.Server, token, name, url string) Feed {
+	t.Helper()
+	res := doRequest(t, ts, "POST", "/api/feeds", token, map[string]string{"name": name, "url": url})
+	expectStatus(t, res, http.StatusCreated)
+
+	feed := Feed{}
+	decodeBody(t, res, &feed)
+	return feed
+}
+
+// createTestPost saves a post for a feed directly in the database.
+func createTestPost(t *testing.T, queries *database.Queries, feed_id uuid.UUID, title string, published_at time.Time) database.UpsertPostRow {
+	t.Helper()
+	url := "https://example.com/" + strings.ReplaceAll(title, " ", "-")
+	post, err := queries.UpsertPost(
+		t.Context(),
+		database.UpsertPostParams{
+			ID:          uuid.New(),
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+			Title:       title,
+			Url:         url,
+			Description: sql.NullString{String: title, Valid: true},
+			PublishedAt: published_at,
+			FeedID:      feed_id,
+			Guid:        url,
+		})
+	if err != nil {
+		t.Fatalf("error creating post: %v", err)
+	}
+	return post
+}
+
+func TestAuthenticatedRoutesRejectMissingToken(t *testing.T) {
+	ts := newOfflineServer(t)
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{"POST", "/api/feeds"},
+		{"DELETE", "/api/feeds/" + uuid.NewString()},
+		{"GET", "/api/follows"},
+		{"POST", "/api/follows"},
+		{"DELETE", "/api/follows/" + uuid.NewString()},
+		{"GET", "/api/posts"},
+	}
+	headers := []string{"", "Basic dXNlcjpwYXNz", "Bearer", "Bearer  "}
+
+	for _, route := range routes {
+		for _, header := range headers {
+			req, err := http.NewRequest(route.method, ts.URL+route.path, nil)
+			if err != nil {
+				t.Fatalf("error building request: %v", err)
+			}
+			if header != "" {
+				req.Header.Set("Authorization", header)
+			}
+			res, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatalf("error sending request: %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusUnauthorized {
+				t.Errorf("%v %v with %q: got status %v, want 401", route.method, route.path, header, res.StatusCode)
+			}
+		}
+	}
+}
+
+func TestAuthenticatedRoutesRejectUnknownToken(t *testing.T) {
+	ts, _ := newTestServer(t)
+	token := createTestUser(t, ts, "alice", "")
+
+	res := doRequest(t, ts, "GET", "/api/follows", token, nil)
+	expectStatus(t, res, http.StatusOK)
+
+	res = doRequest(t, ts, "GET", "/api/follows", token+"0", nil)
+	expectStatus(t, res, http.StatusUnauthorized)
+
+	res = doRequest(t, ts, "GET", "/api/posts", "not-a-token", nil)
+	expectStatus(t, res, http.StatusUnauthorized)
+}
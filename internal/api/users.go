@@ -0,0 +1,136 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/auth"
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+func userFromDB(user database.User) User {
+	return User{
+		ID:        user.ID,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Name:      user.Name,
+	}
+}
+
+func (srv *Server) handleListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := srv.db.ListUsers(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving users", err)
+		return
+	}
+
+	res := []User{}
+	for _, user := range users {
+		res = append(res, userFromDB(user))
+	}
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+func (srv *Server) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+	if params.Name == "" {
+		respondWithError(w, http.StatusBadRequest, "missing name", nil)
+		return
+	}
+
+	// The password is optional, like for register
+	hashed_password := sql.NullString{}
+	if params.Password != "" {
+		hash, err := auth.HashPassword(params.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "error hashing password", err)
+			return
+		}
+		hashed_password = sql.NullString{String: hash, Valid: true}
+	}
+
+	user, err := srv.db.CreateUser(
+		r.Context(),
+		database.CreateUserParams{
+			ID:             uuid.New(),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+			Name:           params.Name,
+			HashedPassword: hashed_password,
+		})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "user already exists", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating user", err)
+		return
+	}
+
+	// The first token lets the new user call the rest of the api
+	srv.respondWithToken(w, r, http.StatusCreated, user)
+}
+
+// handleLogin exchanges a username and password for a new api token.
+func (srv *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	params := parameters{}
+	err := decodeJSON(r, &params)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	user, err := srv.db.GetUserByName(r.Context(), params.Name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, "error retrieving user", err)
+		return
+	}
+	// Unknown users, users without a password and wrong passwords get the
+	// same answer
+	if err != nil || !user.HashedPassword.Valid ||
+		auth.CheckPasswordHash(params.Password, user.HashedPassword.String) != nil {
+		respondWithError(w, http.StatusUnauthorized, "incorrect name or password", nil)
+		return
+	}
+
+	srv.respondWithToken(w, r, http.StatusOK, user)
+}
+
+// respondWithToken creates an api token for the user and responds with the
+// user and the token.
+func (srv *Server) respondWithToken(w http.ResponseWriter, r *http.Request, code int, user database.User) {
+	token, err := auth.CreateToken(r.Context(), srv.db, user.ID, "api")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "error creating token", err)
+		return
+	}
+
+	type response struct {
+		User
+		Token string `json:"token"`
+	}
+	respondWithJSON(w, code, response{User: userFromDB(user), Token: token})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCreateUserRejectsBadBody(t *testing.T) {
+	ts := newOfflineServer(t)
+	bodies := []string{
+		"",
+		"{",
+		`{"name": "alice", "admin": true}`,
+		`{"name": ""}`,
+		`{"password": "secret"}`,
+	}
+
+	for _, body := range bodies {
+		res := doRequest(t, ts, "POST", "/api/users", "", body)
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("POST /api/users %q: got status %v, want 400", body, res.StatusCode)
+		}
+	}
+}
+
+func TestLoginRejectsBadBody(t *testing.T) {
+	ts := newOfflineServer(t)
+	for _, body := range []string{"", "{", `{"name": "alice", "token": "x"}`} {
+		res := doRequest(t, ts, "POST", "/api/login", "", body)
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("POST /api/login %q: got status %v, want 400", body, res.StatusCode)
+		}
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	ts, _ := newTestServer(t)
+	createTestUser(t, ts, "alice", "secret")
+	createTestUser(t, ts, "bob", "")
+
+	res := doRequest(t, ts, "POST", "/api/users", "", map[string]string{"name": "alice"})
+	expectStatus(t, res, http.StatusConflict)
+
+	res = doRequest(t, ts, "GET", "/api/users", "", nil)
+	expectStatus(t, res, http.StatusOK)
+	users := []User{}
+	decodeBody(t, res, &users)
+	if len(users) != 2 {
+		t.Fatalf("got %v users, want 2", len(users))
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ts, _ := newTestServer(t)
+	createTestUser(t, ts, "alice", "secret")
+	createTestUser(t, ts, "bob", "")
+
+	failures := []map[string]string{
+		{"name": "alice", "password": "wrong"},
+		{"name": "alice", "password": ""},
+		{"name": "bob", "password": ""},
+		{"name": "carol", "password": "secret"},
+	}
+	for _, body := range failures {
+		res := doRequest(t, ts, "POST", "/api/login", "", body)
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("login as %v: got status %v, want 401", body["name"], res.StatusCode)
+		}
+	}
+
+	res := doRequest(t, ts, "POST", "/api/login", "", map[string]string{"name": "alice", "password": "secret"})
+	expectStatus(t, res, http.StatusOK)
+	login := struct {
+		Token string `json:"token"`
+	}{}
+	decodeBody(t, res, &login)
+
+	res = doRequest(t, ts, "GET", "/api/follows", login.Token, nil)
+	expectStatus(t, res, http.StatusOK)
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// tokenBytes is the number of random bytes in a token.
+const tokenBytes = 32
+
+// GenerateToken returns a new random api token, hex encoded. Only its hash is
+// stored, so the token must be shown to the user when it is created.
+func GenerateToken() (string, error) {
+	raw := make([]byte, tokenBytes)
+	_, err := rand.Read(raw)
+	if err != nil {
+		return "", fmt.Errorf("error generating token: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// HashToken returns the hash stored in place of a token. Tokens are random
+// and long, so a fast hash is enough to keep them secret.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateToken stores a new api token for the user under name and returns
+// the token. Only its hash is kept in the database.
+func CreateToken(ctx context.Context, db *database.Queries, user_id uuid.UUID, name string) (string, error) {
+	token, err := GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.CreateAPIToken(
+		ctx,
+		database.CreateAPITokenParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UserID:    user_id,
+			Name:      name,
+			TokenHash: HashToken(token),
+		})
+	if err != nil {
+		return "", fmt.Errorf("error saving token: %w", err)
+	}
+	return token, nil
+}
+
+// GetBearerToken returns the token from an "Authorization: Bearer" header.
+func GetBearerToken(headers http.Header) (string, error) {
+	authorization := headers.Get("Authorization")
+	if authorization == "" {
+		return "", errors.New("missing authorization header")
+	}
+
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", errors.New("malformed authorization header")
+	}
+	return strings.TrimSpace(token), nil
+}
@@ -0,0 +1,395 @@
+package commands
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/crisp-coder/gator/internal/rss"
+	"github.com/google/uuid"
+)
+
+func handleAgg(s *State, cmd Command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	workers := fs.Int("workers", 1, "number of feeds fetched in parallel")
+	batch := fs.Int("batch", 1, "number of feeds fetched each tick")
+
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return fmt.Errorf("error parsing agg flags: %w", err)
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("missing time between requests argument to agg command")
+	}
+	if *workers < 1 || *batch < 1 {
+		return fmt.Errorf("workers and batch must be at least 1")
+	}
+
+	timeBetweenRequests, err := time.ParseDuration(args[0])
+	if err != nil {
+		return fmt.Errorf("error parsing time between requests: %w", err)
+	}
+
+	ticker := time.NewTicker(timeBetweenRequests)
+	defer ticker.Stop()
+
+	stats := aggStats{}
+	for {
+		// Keep running when the database is briefly unavailable, the
+		// feeds are claimed again on the next tick.
+		err := ScrapeFeeds(s, *workers, *batch, &stats)
+		if err != nil && s.Ctx.Err() == nil {
+			fmt.Printf("error in scrape feeds: %v\n", err)
+		}
+
+		select {
+		case <-s.Ctx.Done():
+			fmt.Printf("Stopping agg.\n")
+			stats.print()
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// aggStats counts the work done by agg, printed as a summary on exit.
+type aggStats struct {
+	fetched     atomic.Int64
+	notModified atomic.Int64
+	failed      atomic.Int64
+	inserted    atomic.Int64
+	updated     atomic.Int64
+}
+
+func (stats *aggStats) print() {
+	fmt.Printf("Feeds fetched: %v\n", stats.fetched.Load())
+	fmt.Printf("Feeds not modified: %v\n", stats.notModified.Load())
+	fmt.Printf("Feeds failed: %v\n", stats.failed.Load())
+	fmt.Printf("Posts inserted: %v\n", stats.inserted.Load())
+	fmt.Printf("Posts updated: %v\n", stats.updated.Load())
+}
+
+// fetchTimeout bounds the time spent fetching and saving a single feed.
+const fetchTimeout = time.Minute
+
+// recordTimeout bounds the time spent recording a failed fetch or releasing
+// unfetched feeds.
+const recordTimeout = 10 * time.Second
+
+// ScrapeFeeds claims a batch of feeds that are due to be fetched and
+// fetches them using up to workers goroutines. Errors for a single feed are
+// printed and do not stop the other feeds.
+//
+// Feeds already being fetched are finished even if s.Ctx is cancelled, so a
+// shutdown waits for the in-flight feeds instead of abandoning them. Claimed
+// feeds that were not started yet are released.
+func ScrapeFeeds(s *State, workers int, batch int, stats *aggStats) error {
+	feeds, err := claimFeeds(s, batch)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	in_flight_ctx := context.WithoutCancel(s.Ctx)
+	jobs := make(chan database.Feed)
+	wg := sync.WaitGroup{}
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				ctx, cancel := context.WithTimeout(in_flight_ctx, fetchTimeout)
+				err := scrapeFeed(ctx, s, feed, stats)
+				cancel()
+				if err != nil {
+					fmt.Printf("error scraping feed %v: %v\n", feed.Url, err)
+					stats.failed.Add(1)
+					// The fetch context may have timed out, record the
+					// failure on a fresh one so hanging feeds back off.
+					fail_ctx, fail_cancel := context.WithTimeout(in_flight_ctx, recordTimeout)
+					recordFeedFailure(fail_ctx, s, feed, err)
+					fail_cancel()
+				}
+			}
+		}()
+	}
+
+	// On shutdown only the feeds already handed to a worker are finished,
+	// the rest are released to be claimed again by the next run.
+	for i, feed := range feeds {
+		if s.Ctx.Err() == nil {
+			select {
+			case jobs <- feed:
+				continue
+			case <-s.Ctx.Done():
+			}
+		}
+		releaseFeeds(in_flight_ctx, s, feeds[i:])
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	return nil
+}
+
+// releaseFeeds makes claimed feeds due again without fetching them.
+func releaseFeeds(ctx context.Context, s *State, feeds []database.Feed) {
+	ctx, cancel := context.WithTimeout(ctx, recordTimeout)
+	defer cancel()
+
+	ids := []uuid.UUID{}
+	for _, feed := range feeds {
+		ids = append(ids, feed.ID)
+	}
+	err := s.Db.ReleaseFeeds(
+		ctx,
+		database.ReleaseFeedsParams{
+			Now: time.Now(),
+			Ids: ids,
+		})
+	if err != nil {
+		fmt.Printf("error releasing %v unfetched feeds: %v\n", len(feeds), err)
+	}
+}
+
+// claimFeeds marks up to batch due feeds as fetched and returns them. The
+// claim is a single transaction that skips rows locked by other aggregators,
+// so several gator agg processes can share one database.
+func claimFeeds(s *State, batch int) ([]database.Feed, error) {
+	tx, err := s.Conn.BeginTx(s.Ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting claim transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	feeds, err := s.Db.WithTx(tx).ClaimFeedsToFetch(
+		s.Ctx,
+		database.ClaimFeedsToFetchParams{
+			Now:   time.Now(),
+			Batch: int32(batch),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("error claiming feeds: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error committing claimed feeds: %w", err)
+	}
+	return feeds, nil
+}
+
+func scrapeFeed(ctx context.Context, s *State, feed database.Feed, stats *aggStats) error {
+	// Get rss feed data from provider url
+	feed_data, err := rss.FetchFeed(ctx, feed.Url, rss.CacheHeaders{
+		ETag:         feed.Etag.String,
+		LastModified: feed.LastModified.String,
+	})
+	if errors.Is(err, rss.ErrNotModified) {
+		// Nothing new since the last fetch, last_fetched_at is already updated.
+		fmt.Printf("Feed not modified: %v\n", feed.Name)
+		stats.notModified.Add(1)
+		return recordFeedSuccess(ctx, s, feed, 0, 0)
+	}
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	// Save each entry in the feed to the posts table
+	new_posts := 0
+	updated_posts := 0
+	save_failed := false
+	for _, entry := range feed_data.Entries {
+		// Log unparseable publish dates and continue
+		if entry.Published.IsZero() {
+			fmt.Printf("error parsing publish date %q. skipping item %v...\n", entry.PubDate, entry.Title)
+			continue
+		}
+
+		post, err := savePost(ctx, s, feed, entry)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Already saved and unchanged
+			continue
+		}
+		if err != nil {
+			fmt.Printf("error saving post %v: %v\n", entry.ID, err)
+			save_failed = true
+			continue
+		}
+
+		if post.Inserted {
+			new_posts++
+		} else {
+			updated_posts++
+		}
+	}
+	fmt.Printf("Feed %v: %v new posts, %v updated posts\n", feed.Name, new_posts, updated_posts)
+
+	// Remember the validators for the next conditional request only once
+	// every entry is saved, otherwise a 304 would hide the unsaved entries.
+	if !save_failed {
+		err = s.Db.SetFeedCacheHeaders(
+			ctx,
+			database.SetFeedCacheHeadersParams{
+				ID:           feed.ID,
+				Etag:         sql.NullString{String: feed_data.Cache.ETag, Valid: feed_data.Cache.ETag != ""},
+				LastModified: sql.NullString{String: feed_data.Cache.LastModified, Valid: feed_data.Cache.LastModified != ""},
+			})
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+	}
+
+	stats.fetched.Add(1)
+	stats.inserted.Add(int64(new_posts))
+	stats.updated.Add(int64(updated_posts))
+	return recordFeedSuccess(ctx, s, feed, new_posts, feed_data.UpdateInterval)
+}
+
+// savePost inserts or updates the post for an entry. Posts keyed by url
+// before guids were stored are first moved to the entry's guid. When the
+// stored post changed, its previous version is kept in post_revisions in the
+// same transaction. Returns sql.ErrNoRows when the stored post is unchanged.
+func savePost(ctx context.Context, s *State, feed database.Feed, entry rss.Entry) (database.UpsertPostRow, error) {
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.UpsertPostRow{}, fmt.Errorf("%w", err)
+	}
+	defer tx.Rollback()
+	queries := s.Db.WithTx(tx)
+
+	now := time.Now()
+	description := sql.NullString{String: entry.Description, Valid: true}
+
+	if entry.ID != entry.Link {
+		err = queries.RekeyPost(
+			ctx,
+			database.RekeyPostParams{
+				Guid:   entry.ID,
+				FeedID: feed.ID,
+				Url:    entry.Link,
+			})
+		if err != nil {
+			return database.UpsertPostRow{}, fmt.Errorf("error rekeying post: %w", err)
+		}
+	}
+
+	err = queries.CreatePostRevision(
+		ctx,
+		database.CreatePostRevisionParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			FeedID:      feed.ID,
+			Guid:        entry.ID,
+			Title:       entry.Title,
+			Description: description,
+		})
+	if err != nil {
+		return database.UpsertPostRow{}, fmt.Errorf("error saving post revision: %w", err)
+	}
+
+	post, err := queries.UpsertPost(
+		ctx,
+		database.UpsertPostParams{
+			ID:          uuid.New(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			Title:       entry.Title,
+			Url:         entry.Link,
+			Description: description,
+			PublishedAt: entry.Published,
+			FeedID:      feed.ID,
+			Guid:        entry.ID,
+		})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return database.UpsertPostRow{}, err
+	}
+
+	// Unchanged posts are committed too, to keep the rekey above
+	commit_err := tx.Commit()
+	if commit_err != nil {
+		return database.UpsertPostRow{}, fmt.Errorf("%w", commit_err)
+	}
+	return post, err
+}
+
+const (
+	minFetchInterval = 10 * time.Minute
+	maxFetchInterval = 24 * time.Hour
+)
+
+// nextFetchInterval adapts a feed's interval to how often it publishes. The
+// interval is halved when the fetch found new posts and grows by half when
+// it did not. The publisher's own update interval is a lower bound.
+func nextFetchInterval(current time.Duration, new_posts int, update_interval time.Duration) time.Duration {
+	interval := current * 3 / 2
+	if new_posts > 0 {
+		interval = current / 2
+	}
+	if interval < update_interval {
+		interval = update_interval
+	}
+	return min(max(interval, minFetchInterval), maxFetchInterval)
+}
+
+func recordFeedSuccess(ctx context.Context, s *State, feed database.Feed, new_posts int, update_interval time.Duration) error {
+	current := time.Duration(feed.FetchIntervalSeconds) * time.Second
+	interval := nextFetchInterval(current, new_posts, update_interval)
+
+	now := time.Now()
+	err := s.Db.RecordFeedSuccess(
+		ctx,
+		database.RecordFeedSuccessParams{
+			ID:                   feed.ID,
+			FetchIntervalSeconds: int32(interval.Seconds()),
+			NextFetchAt:          sql.NullTime{Time: now.Add(interval), Valid: true},
+			LastSuccessAt:        sql.NullTime{Time: now, Valid: true},
+		})
+	if err != nil {
+		return fmt.Errorf("error scheduling next fetch: %w", err)
+	}
+	return nil
+}
+
+// maxFeedFailures is the number of consecutive failures after which a feed
+// is paused until it is resumed with the resume command.
+const maxFeedFailures = 10
+
+// failureBackoff doubles the wait after each consecutive failure, starting
+// at minFetchInterval and capped at maxFetchInterval.
+func failureBackoff(failures int32) time.Duration {
+	backoff := minFetchInterval
+	for i := int32(1); i < failures && backoff < maxFetchInterval; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxFetchInterval)
+}
+
+func recordFeedFailure(ctx context.Context, s *State, feed database.Feed, fetch_err error) {
+	failures := feed.ConsecutiveFailures + 1
+	paused := failures >= maxFeedFailures
+
+	err := s.Db.RecordFeedFailure(
+		ctx,
+		database.RecordFeedFailureParams{
+			ID:                  feed.ID,
+			ConsecutiveFailures: failures,
+			LastError:           sql.NullString{String: fetch_err.Error(), Valid: true},
+			NextFetchAt:         sql.NullTime{Time: time.Now().Add(failureBackoff(failures)), Valid: true},
+			Paused:              paused,
+		})
+	if err != nil {
+		fmt.Printf("error recording failure for feed %v: %v\n", feed.Url, err)
+		return
+	}
+
+	if paused {
+		fmt.Printf("Paused feed %v after %v consecutive failures\n", feed.Url, failures)
+	}
+}
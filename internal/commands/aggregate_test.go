@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/crisp-coder/gator/internal/dbtest"
+	"github.com/crisp-coder/gator/internal/rss"
+	"github.com/google/uuid"
+)
+
+func newTestState(t *testing.T) *State {
+	t.Helper()
+	db := dbtest.Open(t, "gator_test_commands")
+	return &State{Ctx: t.Context(), Db: database.New(db), Conn: db}
+}
+
+func createTestFeed(t *testing.T, s *State) database.Feed {
+	t.Helper()
+	user, err := s.Db.CreateUser(
+		s.Ctx,
+		database.CreateUserParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      "alice",
+		})
+	if err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+
+	feed, err := s.Db.CreateFeed(
+		s.Ctx,
+		database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			Name:      "blog",
+			Url:       "https://example.com/feed",
+			UserID:    user.ID,
+		})
+	if err != nil {
+		t.Fatalf("error creating feed: %v", err)
+	}
+	return feed
+}
+
+func feedGuids(t *testing.T, s *State, feed database.Feed) []string {
+	t.Helper()
+	rows, err := s.Conn.QueryContext(s.Ctx, "SELECT guid FROM posts WHERE feed_id = $1 ORDER BY guid", feed.ID)
+	if err != nil {
+		t.Fatalf("error listing posts: %v", err)
+	}
+	defer rows.Close()
+
+	guids := []string{}
+	for rows.Next() {
+		guid := ""
+		err := rows.Scan(&guid)
+		if err != nil {
+			t.Fatalf("error scanning post: %v", err)
+		}
+		guids = append(guids, guid)
+	}
+	return guids
+}
+
+func TestSavePostRekeysUnchangedMigratedPost(t *testing.T) {
+	s := newTestState(t)
+	feed := createTestFeed(t, s)
+
+	entry := rss.Entry{
+		ID:          "https://example.com/?p=1",
+		Title:       "First post",
+		Link:        "https://example.com/first-post",
+		Description: "Hello",
+		Published:   time.Now().Truncate(time.Second),
+	}
+
+	// Posts saved before guids were stored are keyed by their link
+	migrated := entry
+	migrated.ID = migrated.Link
+	_, err := savePost(s.Ctx, s, feed, migrated)
+	if err != nil {
+		t.Fatalf("error saving migrated post: %v", err)
+	}
+
+	_, err = savePost(s.Ctx, s, feed, entry)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("saving unchanged post: got %v, want sql.ErrNoRows", err)
+	}
+	guids := feedGuids(t, s, feed)
+	if len(guids) != 1 || guids[0] != entry.ID {
+		t.Fatalf("got guids %q, want [%q]", guids, entry.ID)
+	}
+
+	// Once rekeyed, a new link updates the same post
+	entry.Link = "https://example.com/first-post-renamed"
+	post, err := savePost(s.Ctx, s, feed, entry)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("error saving moved post: %v", err)
+	}
+	if err == nil && post.Inserted {
+		t.Errorf("moved post was inserted again")
+	}
+	guids = feedGuids(t, s, feed)
+	if len(guids) != 1 {
+		t.Errorf("got guids %q after the link changed, want one post", guids)
+	}
+}
@@ -2,7 +2,6 @@ package commands
 
 import (
 	"errors"
-	"fmt"
 )
 
 type Commands struct {
@@ -16,10 +15,6 @@ type Command struct {
 
 func (cmds *Commands) Run(s *State, cmd Command) error {
 	if f, ok := cmds.cmd_map[cmd.Name]; ok {
-		fmt.Printf("command: %v\n", cmd.Name)
-		for i, arg := range cmd.Args {
-			fmt.Printf("arg[%v]: %v\n", i, arg)
-		}
 		return f(s, cmd)
 	} else {
 		return errors.New("command not found")
@@ -37,16 +32,32 @@ func MakeCommands() Commands {
 
 	cmds.Register("help", handlerHelp)
 	cmds.Register("login", handlerLogin)
+	cmds.Register("bootstrap", handleBootstrap)
 	cmds.Register("register", handlerRegister)
+	cmds.Register("passwd", middlewareLoggedIn(handlePasswd))
 	cmds.Register("reset", handleReset)
 	cmds.Register("users", handleListUsers)
 	cmds.Register("agg", handleAgg)
 	cmds.Register("addfeed", middlewareLoggedIn(handleAddFeed))
 	cmds.Register("feeds", handleListFeeds)
+	cmds.Register("resume", handleResume)
 	cmds.Register("follow", middlewareLoggedIn(handleFollow))
 	cmds.Register("following", middlewareLoggedIn(handleFollowing))
 	cmds.Register("unfollow", middlewareLoggedIn(handleUnfollow))
 	cmds.Register("browse", middlewareLoggedIn(handleBrowse))
+	cmds.Register("history", handleHistory)
+	cmds.Register("read", middlewareLoggedIn(handleRead))
+	cmds.Register("unread", middlewareLoggedIn(handleUnread))
+	cmds.Register("star", middlewareLoggedIn(handleStar))
+	cmds.Register("unstar", middlewareLoggedIn(handleUnstar))
+	cmds.Register("starred", middlewareLoggedIn(handleStarred))
+	cmds.Register("prune", handlePrune)
+	cmds.Register("search", middlewareLoggedIn(handleSearch))
+	cmds.Register("export", middlewareLoggedIn(handleExport))
+	cmds.Register("import", middlewareLoggedIn(handleImport))
+	cmds.Register("serve", handleServe)
+	cmds.Register("token", middlewareLoggedIn(handleToken))
+	cmds.Register("fever", middlewareLoggedIn(handleFever))
 
 	return cmds
 }
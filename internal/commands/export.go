@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/crisp-coder/gator/internal/opml"
+)
+
+// exporters write one kind of user data for the export command.
+var exporters = map[string]func(s *State, user database.User, w io.Writer) error{
+	"starred": exportStarred,
+	"opml":    exportOPML,
+}
+
+func handleExport(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage: export <kind> [file]")
+	}
+
+	exporter, ok := exporters[cmd.Args[0]]
+	if !ok {
+		return fmt.Errorf("unknown export kind: %v", cmd.Args[0])
+	}
+
+	// Write to stdout unless a file is given
+	if len(cmd.Args) == 1 {
+		return exporter(s, user, os.Stdout)
+	}
+
+	file, err := os.Create(cmd.Args[1])
+	if err != nil {
+		return fmt.Errorf("error creating export file: %w", err)
+	}
+	defer file.Close()
+
+	err = exporter(s, user, file)
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
+
+type starredPost struct {
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Feed        string    `json:"feed"`
+	Description string    `json:"description"`
+	PublishedAt time.Time `json:"published_at"`
+	StarredAt   time.Time `json:"starred_at"`
+}
+
+func exportStarred(s *State, user database.User, w io.Writer) error {
+	posts, err := s.Db.GetStarredPostsForUser(s.Ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving starred posts: %w", err)
+	}
+
+	starred := []starredPost{}
+	for _, post := range posts {
+		starred = append(starred, starredPost{
+			Title:       post.Title,
+			URL:         post.Url,
+			Feed:        post.Feedname,
+			Description: post.Description.String,
+			PublishedAt: post.PublishedAt,
+			StarredAt:   post.StarredAt,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(starred)
+	if err != nil {
+		return fmt.Errorf("error writing starred posts: %w", err)
+	}
+	return nil
+}
+
+func exportOPML(s *State, user database.User, w io.Writer) error {
+	feed_follows, err := s.Db.GetFeedFollowsForUser(s.Ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving feed follows: %w", err)
+	}
+
+	subscriptions := []opml.Subscription{}
+	for _, ff := range feed_follows {
+		subscriptions = append(subscriptions, opml.Subscription{
+			Title:    ff.Feedname,
+			XMLURL:   ff.Url,
+			Category: ff.Category.String,
+		})
+	}
+
+	// Group folders together and keep the output stable between exports
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		if subscriptions[i].Category != subscriptions[j].Category {
+			return subscriptions[i].Category < subscriptions[j].Category
+		}
+		return subscriptions[i].Title < subscriptions[j].Title
+	})
+
+	title := fmt.Sprintf("gator subscriptions for %v", user.Name)
+	return opml.New(title, time.Now(), subscriptions).Write(w)
+}
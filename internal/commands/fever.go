@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/auth"
+	"github.com/crisp-coder/gator/internal/database"
+)
+
+// feverCommands are the subcommands of the fever command.
+var feverCommands = map[string]func(s *State, user database.User) error{
+	"enable":  handleFeverEnable,
+	"disable": handleFeverDisable,
+}
+
+func handleFever(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: fever <enable|disable>")
+	}
+
+	subcommand, ok := feverCommands[cmd.Args[0]]
+	if !ok {
+		return fmt.Errorf("unknown fever command: %v", cmd.Args[0])
+	}
+	return subcommand(s, user)
+}
+
+// handleFeverEnable stores the hash of the Fever api key for the user. The
+// key is derived from the password, which is asked for again as only its
+// bcrypt hash is stored.
+func handleFeverEnable(s *State, user database.User) error {
+	if !user.HashedPassword.Valid {
+		return errors.New("fever needs a password, set one with passwd")
+	}
+
+	password, err := readPassword("Password: ")
+	if err != nil {
+		return err
+	}
+	err = auth.CheckPasswordHash(password, user.HashedPassword.String)
+	if err != nil {
+		return errors.New("incorrect password")
+	}
+
+	err = s.Db.SetUserFeverAPIKey(
+		s.Ctx,
+		database.SetUserFeverAPIKeyParams{
+			ID:              user.ID,
+			FeverApiKeyHash: sql.NullString{String: auth.HashToken(auth.FeverAPIKey(user.Name, password)), Valid: true},
+			UpdatedAt:       time.Now(),
+		})
+	if err != nil {
+		return fmt.Errorf("error enabling fever: %w", err)
+	}
+
+	fmt.Printf("Fever enabled for user: %v\n", user.Name)
+	return nil
+}
+
+func handleFeverDisable(s *State, user database.User) error {
+	err := s.Db.SetUserFeverAPIKey(
+		s.Ctx,
+		database.SetUserFeverAPIKeyParams{
+			ID:        user.ID,
+			UpdatedAt: time.Now(),
+		})
+	if err != nil {
+		return fmt.Errorf("error disabling fever: %w", err)
+	}
+
+	fmt.Printf("Fever disabled for user: %v\n", user.Name)
+	return nil
+}
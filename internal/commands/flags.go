@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"flag"
+)
+
+// parseFlags parses flags that may appear before, between or after the
+// positional arguments, e.g. "agg 1m --workers 4", and returns the
+// positional arguments in order.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
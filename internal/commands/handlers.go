@@ -1,45 +1,100 @@
 package commands
 
 import (
-	"context"
 	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/crisp-coder/gator/internal/auth"
+	"github.com/crisp-coder/gator/internal/cursor"
 	"github.com/crisp-coder/gator/internal/database"
-	"github.com/crisp-coder/gator/internal/rss"
+	"github.com/crisp-coder/gator/internal/dates"
 	"github.com/google/uuid"
 )
 
 func handlerHelp(s *State, cmd Command) error {
 	fmt.Printf("help\n")
-	fmt.Printf("login <username> - logs in the user.\n")
-	fmt.Printf("register <username> - adds a user to the database and automatically logs in the user.\n")
+	fmt.Printf("login <username> - logs in the user with their password, or one of their api tokens when they have none.\n")
+	fmt.Printf("bootstrap <username> - issues the first api token of a user created before tokens existed, once.\n")
+	fmt.Printf("register <username> - adds a user with an optional password and automatically logs in the user with a new api token.\n")
+	fmt.Printf("passwd - sets, changes or removes the password of the current user.\n")
+	fmt.Printf("token create <name> - creates an api token for the cli or the http api.\n")
+	fmt.Printf("token list - lists the users api tokens.\n")
+	fmt.Printf("token revoke <id> - revokes an api token.\n")
+	fmt.Printf("fever <enable|disable> - allows or stops Fever api clients logging in with the users password.\n")
 	fmt.Printf("reset - drops rows data but keep tables.\n")
 	fmt.Printf("users - lists all users.\n")
 	fmt.Printf("feeds - lists all feeds.\n")
-	fmt.Printf("addfeed <name> <url>\n")
-	fmt.Printf("agg - print rss feeds to console.\n")
-	fmt.Printf("follow <url> - adds the feed for the url to the users follows.\n")
+	fmt.Printf("resume <url> - resumes fetching a feed paused after repeated failures.\n")
+	fmt.Printf("addfeed <name> <url> - adds a feed, the url may be a website with a feed.\n")
+	fmt.Printf("agg <duration> [--workers N] [--batch M] - fetch M feeds every duration using N parallel workers.\n")
+	fmt.Printf("follow <url> [category] - adds the feed for the url, or the website of a feed, to the users follows.\n")
 	fmt.Printf("following - lists all feeds followed by the current user.\n")
 	fmt.Printf("unfollow <url> - removes follow for url for current user.\n")
-	fmt.Printf("browse <limit> - prints up to limit posts for user feeds.\n")
+	fmt.Printf("import <file.opml> - adds and follows the feeds in an opml file.\n")
+	fmt.Printf("export opml [file] - writes followed feeds as opml to file or stdout.\n")
+	fmt.Printf("browse [limit] [--all] [--feed <url|name>] [--since <duration>] [--before <date>] [--grep <text>] [--after <cursor>] - prints up to limit unread posts for user feeds and marks them read, --all includes read posts.\n")
+	fmt.Printf("read <post> - marks a post as read.\n")
+	fmt.Printf("unread <post> - marks a post as unread.\n")
+	fmt.Printf("star <post> - saves a post to the starred list.\n")
+	fmt.Printf("unstar <post> - removes a post from the starred list.\n")
+	fmt.Printf("starred - lists starred posts.\n")
+	fmt.Printf("export starred [file] - writes starred posts as json to file or stdout.\n")
+	fmt.Printf("prune <age> - deletes posts published more than age ago, except starred posts.\n")
+	fmt.Printf("history <post> - shows how a post changed between revisions.\n")
+	fmt.Printf("search <query> [--all] [--limit N] - full text search of posts in followed feeds, --all searches every feed.\n")
+	fmt.Printf("serve [--addr :8080] - serves the json api over http until interrupted.\n")
 	return nil
 }
 
 func handlerLogin(s *State, cmd Command) error {
-	if len(cmd.Args) == 0 {
-		return errors.New("missing arguments to login command")
+	if len(cmd.Args) != 1 {
+		return errors.New("usage: login <username>")
 	}
 
-	user_res, err := s.Db.GetUserByName(context.Background(), cmd.Args[0])
+	user_res, err := s.Db.GetUserByName(s.Ctx, cmd.Args[0])
 	if err != nil {
 		return fmt.Errorf("error retrieving user from database: %w", err)
 	}
 
-	err = s.Cfg.SetUser(user_res.Name)
+	// Secrets are read without echo rather than from the arguments, which
+	// end up in the shell history and the process list.
+	token := ""
+	if user_res.HashedPassword.Valid {
+		password, err := readPassword("Password: ")
+		if err != nil {
+			return err
+		}
+		err = auth.CheckPasswordHash(password, user_res.HashedPassword.String)
+		if err != nil {
+			return errors.New("incorrect password")
+		}
+		token, err = auth.CreateToken(s.Ctx, s.Db, user_res.ID, "cli")
+		if err != nil {
+			return fmt.Errorf("error creating token: %w", err)
+		}
+	} else if user_res.NeedsFirstToken {
+		return fmt.Errorf("user %v has no password or tokens yet, run bootstrap %v", user_res.Name, user_res.Name)
+	} else {
+		token, err = readPassword("Token: ")
+		if err != nil {
+			return err
+		}
+		token_user, err := s.Db.GetUserByAPIToken(
+			s.Ctx,
+			database.GetUserByAPITokenParams{
+				Now:       time.Now(),
+				TokenHash: auth.HashToken(token),
+			})
+		if err != nil || token_user.ID != user_res.ID {
+			return errors.New("invalid token for user")
+		}
+	}
+
+	err = s.Cfg.SetUser(user_res.Name, token)
 	if err != nil {
 		return fmt.Errorf("error handling login: %w", err)
 	}
@@ -53,164 +108,128 @@ func handlerRegister(s *State, cmd Command) error {
 		return errors.New("missing arguments to register command")
 	}
 
+	hashed_password, err := readNewPassword()
+	if err != nil {
+		return fmt.Errorf("error setting password: %w", err)
+	}
+
 	user_res, err := s.Db.CreateUser(
-		context.Background(),
+		s.Ctx,
 		database.CreateUserParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-			Name:      cmd.Args[0],
+			ID:             uuid.New(),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+			Name:           cmd.Args[0],
+			HashedPassword: hashed_password,
 		})
 
 	if err != nil {
 		return fmt.Errorf("error creating user: %w", err)
 	}
 
-	err = s.Cfg.SetUser(user_res.Name)
+	token, err := auth.CreateToken(s.Ctx, s.Db, user_res.ID, "cli")
+	if err != nil {
+		return fmt.Errorf("error creating token: %w", err)
+	}
+
+	err = s.Cfg.SetUser(user_res.Name, token)
 	if err != nil {
 		return fmt.Errorf("error handling register: %w", err)
 	}
 
 	fmt.Printf("User: %v has been set.\n", s.Cfg.Username)
+	fmt.Printf("Token: %v\n", token)
 
 	return nil
 }
 
-func handleReset(s *State, cmd Command) error {
+func handlePasswd(s *State, cmd Command, user database.User) error {
 	if len(cmd.Args) != 0 {
-		return errors.New("too many arguments to reset command")
+		return errors.New("too many arguments to passwd command")
 	}
 
-	err := s.Db.Reset(context.Background())
-
-	if err != nil {
-		return fmt.Errorf("failed to reset users: %w", err)
+	// The token alone is not enough to replace an existing password
+	if user.HashedPassword.Valid {
+		password, err := readPassword("Current password: ")
+		if err != nil {
+			return err
+		}
+		err = auth.CheckPasswordHash(password, user.HashedPassword.String)
+		if err != nil {
+			return errors.New("incorrect password")
+		}
 	}
 
-	return nil
-}
-
-func handleListUsers(s *State, cmd Command) error {
-	if len(cmd.Args) != 0 {
-		return fmt.Errorf("too many arguments to list users command")
+	hashed_password, err := readNewPassword()
+	if err != nil {
+		return fmt.Errorf("error setting password: %w", err)
 	}
 
-	users_res, err := s.Db.ListUsers(context.Background())
-
+	err = s.Db.SetUserPassword(
+		s.Ctx,
+		database.SetUserPasswordParams{
+			ID:             user.ID,
+			HashedPassword: hashed_password,
+			UpdatedAt:      time.Now(),
+		})
 	if err != nil {
-		return fmt.Errorf("filed to list users: %w", err)
+		return fmt.Errorf("error saving password: %w", err)
 	}
 
-	for _, val := range users_res {
-		fmt.Printf(" - %v", val.Name)
-		if val.Name == s.Cfg.Username {
-			fmt.Printf(" (current)\n")
-		} else {
-			fmt.Printf("\n")
+	// The fever key is derived from the old password
+	if user.FeverApiKeyHash.Valid {
+		err = s.Db.SetUserFeverAPIKey(
+			s.Ctx,
+			database.SetUserFeverAPIKeyParams{
+				ID:        user.ID,
+				UpdatedAt: time.Now(),
+			})
+		if err != nil {
+			return fmt.Errorf("error disabling fever: %w", err)
 		}
+		fmt.Printf("Fever disabled, run fever enable to use the new password.\n")
 	}
 
+	if hashed_password.Valid {
+		fmt.Printf("Password changed for user: %v\n", user.Name)
+	} else {
+		fmt.Printf("Password removed for user: %v\n", user.Name)
+	}
 	return nil
 }
 
-func handleAgg(s *State, cmd Command) error {
-	if len(cmd.Args) != 1 {
-		return fmt.Errorf("missing time between requests argument to agg command")
+func handleReset(s *State, cmd Command) error {
+	if len(cmd.Args) != 0 {
+		return errors.New("too many arguments to reset command")
 	}
 
-	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
+	err := s.Db.Reset(s.Ctx)
+
 	if err != nil {
-		return fmt.Errorf("error parsing time between requests: %w", err)
+		return fmt.Errorf("failed to reset users: %w", err)
 	}
 
-	ticker := time.NewTicker(timeBetweenRequests)
-
-	for ; ; <-ticker.C {
-		err := ScrapeFeeds(s)
-		if err != nil {
-			return fmt.Errorf("error in scrape feeds: %w", err)
-		}
-	}
+	return nil
 }
 
-func ScrapeFeeds(s *State) error {
-	// Get next feed in database
-	feed, err := s.Db.GetNextFeedToFetch(context.Background())
-	if err != nil {
-		return fmt.Errorf("%w", err)
+func handleListUsers(s *State, cmd Command) error {
+	if len(cmd.Args) != 0 {
+		return fmt.Errorf("too many arguments to list users command")
 	}
 
-	// Update feed time in database
-	_, err = s.Db.MarkFeedFetched(
-		context.Background(),
-		database.MarkFeedFetchedParams{
-			ID:            feed.ID,
-			LastFetchedAt: sql.NullTime{Time: time.Now(), Valid: true},
-		})
-
-	if err != nil {
-		return fmt.Errorf("%w", err)
-	}
+	users_res, err := s.Db.ListUsers(s.Ctx)
 
-	// Get rss feed data from provider url
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	rss_feed, err := rss.FetchFeed(ctx, feed.Url)
 	if err != nil {
-		return fmt.Errorf("%w", err)
+		return fmt.Errorf("filed to list users: %w", err)
 	}
 
-	layouts := []string{
-		time.RFC1123Z, // "Mon, 02 Jan 2006 15:04:05 -0700"
-		time.RFC1123,  // "Mon, 02 Jan 2006 15:04:05 MST"
-		time.RFC822Z,  // "02 Jan 06 15:04 -0700"
-		time.RFC822,   // "02 Jan 06 15:04 MST"
-		time.RFC3339,  // "2006-01-02T15:04:05Z07:00"
-	}
-
-	// Save each item in the rss feed to the posts table
-	for _, item := range rss_feed.Channel.Item {
-		// Try to parse publish date with all date layouts
-		var parse_err error
-		var published_at time.Time
-		for _, layout := range layouts {
-			t, err := time.Parse(layout, item.PubDate)
-			if err == nil {
-				published_at = t
-				parse_err = nil
-				break
-			} else {
-				parse_err = err
-			}
-
-		}
-		// Log error and continue
-		if parse_err != nil {
-			fmt.Println(fmt.Errorf("%w", parse_err))
-			fmt.Printf("error parsing publish date. skipping item %v...\n", item)
-			continue
-		}
-
-		post, err := s.Db.CreatePost(
-			context.Background(),
-			database.CreatePostParams{
-				ID:          uuid.New(),
-				CreatedAt:   time.Now(),
-				UpdatedAt:   time.Now(),
-				Title:       item.Title,
-				Url:         item.Link,
-				Description: sql.NullString{String: item.Description, Valid: true},
-				PublishedAt: published_at,
-				FeedID:      feed.ID,
-			})
-
-		if err != nil {
-			// Note that the create query fails on duplicate items, so
-			// this normally gets printed a lot.
-			//fmt.Printf("error saving post to database: %v", err)
-			continue
+	for _, val := range users_res {
+		fmt.Printf(" - %v", val.Name)
+		if val.Name == s.Cfg.Username {
+			fmt.Printf(" (current)\n")
+		} else {
+			fmt.Printf("\n")
 		}
-		fmt.Printf("Saved post: %v\n", post)
 	}
 
 	return nil
@@ -222,11 +241,16 @@ func handleAddFeed(s *State, cmd Command, user database.User) error {
 	}
 
 	feedname := cmd.Args[0]
-	url := cmd.Args[1]
+
+	// Accept a website url and find its feed
+	url, err := discoverFeedURL(s, cmd.Args[1])
+	if err != nil {
+		return fmt.Errorf("error finding feed: %w", err)
+	}
 
 	// Add the feed to the database
 	feed_res, err := s.Db.CreateFeed(
-		context.Background(),
+		s.Ctx,
 		database.CreateFeedParams{
 			ID:        uuid.New(),
 			CreatedAt: time.Now(),
@@ -242,7 +266,7 @@ func handleAddFeed(s *State, cmd Command, user database.User) error {
 
 	// Automatically add feed follow for logged in user.
 	follow_res, err := s.Db.CreateFeedFollow(
-		context.Background(),
+		s.Ctx,
 		database.CreateFeedFollowParams{
 			ID:        uuid.New(),
 			CreatedAt: time.Now(),
@@ -266,7 +290,7 @@ func handleListFeeds(s *State, cmd Command) error {
 		return fmt.Errorf("too many arguments to feeds")
 	}
 
-	feeds, err := s.Db.ListFeeds(context.Background())
+	feeds, err := s.Db.ListFeeds(s.Ctx)
 	if err != nil {
 		return fmt.Errorf("error retrieving feeds: %w", err)
 	}
@@ -277,30 +301,72 @@ func handleListFeeds(s *State, cmd Command) error {
 		}
 		fmt.Printf("Feed: %v\n", feed.Name)
 		fmt.Printf("URL: %v\n", feed.Url)
+		switch {
+		case feed.Paused:
+			fmt.Printf("Status: paused after %v failures\n", feed.ConsecutiveFailures)
+		case feed.ConsecutiveFailures > 0:
+			fmt.Printf("Status: failing, %v consecutive failures\n", feed.ConsecutiveFailures)
+		default:
+			fmt.Printf("Status: ok\n")
+		}
+		if feed.LastError.Valid {
+			fmt.Printf("Last error: %v\n", feed.LastError.String)
+		}
+		if feed.LastSuccessAt.Valid {
+			fmt.Printf("Last success: %v\n", feed.LastSuccessAt.Time)
+		}
 	}
 
 	return nil
 }
 
-func handleFollow(s *State, cmd Command, user database.User) error {
+func handleResume(s *State, cmd Command) error {
 	if len(cmd.Args) != 1 {
+		return fmt.Errorf("missing url argument to resume command")
+	}
+
+	feed, err := s.Db.ResumeFeed(s.Ctx, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error resuming feed: %w", err)
+	}
+
+	fmt.Printf("Resumed feed: %v\n", feed.Name)
+	return nil
+}
+
+func handleFollow(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
 		return fmt.Errorf("missing arguments for follow command")
 	}
 
 	url := cmd.Args[0]
-	feed_res, err := s.Db.GetFeedByURL(context.Background(), url)
+	category := sql.NullString{}
+	if len(cmd.Args) == 2 {
+		category = sql.NullString{String: cmd.Args[1], Valid: true}
+	}
+
+	feed_res, err := s.Db.GetFeedByURL(s.Ctx, url)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Not a known feed url, it may be the website of a known feed
+		url, err = discoverFeedURL(s, url)
+		if err != nil {
+			return fmt.Errorf("error finding feed: %w", err)
+		}
+		feed_res, err = s.Db.GetFeedByURL(s.Ctx, url)
+	}
 	if err != nil {
 		return fmt.Errorf("error getting feed by url: %w", err)
 	}
 
 	follow_res, err := s.Db.CreateFeedFollow(
-		context.Background(),
+		s.Ctx,
 		database.CreateFeedFollowParams{
 			ID:        uuid.New(),
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 			UserID:    user.ID,
 			FeedID:    feed_res.ID,
+			Category:  category,
 		})
 
 	if err != nil {
@@ -314,7 +380,7 @@ func handleFollow(s *State, cmd Command, user database.User) error {
 }
 
 func handleFollowing(s *State, cmd Command, user database.User) error {
-	feed_follows, err := s.Db.GetFeedFollowsForUser(context.Background(), user.ID)
+	feed_follows, err := s.Db.GetFeedFollowsForUser(s.Ctx, user.ID)
 	if err != nil {
 		return fmt.Errorf("%w", err)
 	}
@@ -323,6 +389,9 @@ func handleFollowing(s *State, cmd Command, user database.User) error {
 	for _, ff := range feed_follows {
 		fmt.Printf("Feed: %v\n", ff.Feedname)
 		fmt.Printf("URL: %v\n", ff.Url)
+		if ff.Category.Valid {
+			fmt.Printf("Category: %v\n", ff.Category.String)
+		}
 	}
 
 	return nil
@@ -334,13 +403,13 @@ func handleUnfollow(s *State, cmd Command, user database.User) error {
 	}
 	url := cmd.Args[0]
 
-	feed, err := s.Db.GetFeedByURL(context.Background(), url)
+	feed, err := s.Db.GetFeedByURL(s.Ctx, url)
 	if err != nil {
 		return fmt.Errorf("%w", err)
 	}
 	fmt.Printf("deleting feed: %v", feed)
 
-	err = s.Db.DeleteFeedFollow(context.Background(),
+	err = s.Db.DeleteFeedFollow(s.Ctx,
 		database.DeleteFeedFollowParams{
 			UserID: user.ID,
 			FeedID: feed.ID,
@@ -354,31 +423,87 @@ func handleUnfollow(s *State, cmd Command, user database.User) error {
 }
 
 func handleBrowse(s *State, cmd Command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	all := fs.Bool("all", false, "include posts that were already read")
+	feed := fs.String("feed", "", "only posts from the feed with this url or name")
+	since := fs.Duration("since", 0, "only posts published within this duration")
+	before := fs.String("before", "", "only posts published before this date")
+	grep := fs.String("grep", "", "only posts with this text in the title or description")
+	after := ""
+	fs.StringVar(&after, "after", "", "cursor printed at the end of the previous page")
+	fs.StringVar(&after, "page", "", "same as --after")
+
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return fmt.Errorf("error parsing browse flags: %w", err)
+	}
+
+	params := database.GetPostsForUserParams{
+		UserID:      user.ID,
+		IncludeRead: *all,
+		Feed:        sql.NullString{String: *feed, Valid: *feed != ""},
+		Grep:        sql.NullString{String: *grep, Valid: *grep != ""},
+	}
+	if *since > 0 {
+		params.Since = sql.NullTime{Time: time.Now().Add(-*since), Valid: true}
+	}
+	if *before != "" {
+		before_time, err := dates.Parse(*before)
+		if err != nil {
+			return fmt.Errorf("error parsing before date: %w", err)
+		}
+		params.Before = sql.NullTime{Time: before_time, Valid: true}
+	}
+	if after != "" {
+		c, err := cursor.Decode(after)
+		if err != nil {
+			return err
+		}
+		params.AfterPublishedAt = sql.NullTime{Time: c.PublishedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+
 	limit := int64(2)
-	var err error
-	if len(cmd.Args) == 1 {
-		limit, err = strconv.ParseInt(cmd.Args[0], 10, 32)
+	if len(args) == 1 {
+		limit, err = strconv.ParseInt(args[0], 10, 32)
 		if err != nil {
 			return fmt.Errorf("error parsing limit: %w", err)
 		}
 	}
 
-	posts, err := s.Db.GetPostsForUser(
-		context.Background(),
-		database.GetPostsForUserParams{
-			UserID:  user.ID,
-			Column2: limit,
-		})
+	params.MaxPosts = limit
+	posts, err := s.Db.GetPostsForUser(s.Ctx, params)
 
 	if err != nil {
 		return fmt.Errorf("error retrieving posts for user: %w", err)
 	}
 
 	for _, post := range posts {
+		fmt.Printf("ID: %v\n", post.ID)
+		fmt.Printf("Feed: %v\n", post.Feedname)
 		fmt.Printf("Title: %v\n", post.Title)
 		fmt.Printf("Link: %v\n", post.Url)
-		fmt.Printf("Description: %v\n", post.Description)
+		fmt.Printf("Description: %v\n", post.Description.String)
 		fmt.Printf("PubDate: %v\n", post.PublishedAt)
+		if post.IsRead {
+			fmt.Printf("Read: yes\n")
+		}
+		if post.IsStarred {
+			fmt.Printf("Starred: yes\n")
+		}
+
+		// Displayed posts count as read
+		err = markPostRead(s, user, post.ID)
+		if err != nil {
+			return fmt.Errorf("error marking post read: %w", err)
+		}
+	}
+
+	// A full page means there may be more posts
+	if len(posts) > 0 && int64(len(posts)) == limit {
+		last := posts[len(posts)-1]
+		next := cursor.Cursor{PublishedAt: last.PublishedAt, ID: last.ID}
+		fmt.Printf("Next page: --after %v\n", next.Encode())
 	}
 
 	return nil
@@ -1,20 +1,31 @@
 package commands
 
 import (
-	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/crisp-coder/gator/internal/auth"
 	"github.com/crisp-coder/gator/internal/database"
 )
 
 func middlewareLoggedIn(handler func(s *State, cmd Command, user database.User) error) func(*State, Command) error {
 	return func(s *State, cmd Command) error {
 
-		if s.Cfg.Username == "" {
+		if s.Cfg.Token == "" {
 			return fmt.Errorf("not logged in")
 		}
 
-		logged_in_user, err := s.Db.GetUserByName(context.Background(), s.Cfg.Username)
+		logged_in_user, err := s.Db.GetUserByAPIToken(
+			s.Ctx,
+			database.GetUserByAPITokenParams{
+				Now:       time.Now(),
+				TokenHash: auth.HashToken(s.Cfg.Token),
+			})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("token is invalid or revoked, log in again")
+		}
 		if err != nil {
 			return fmt.Errorf("error retrieving user data: %w", err)
 		}
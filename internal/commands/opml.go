@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/crisp-coder/gator/internal/opml"
+	"github.com/google/uuid"
+)
+
+func handleImport(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("missing file argument to import command")
+	}
+
+	file, err := os.Open(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error opening opml file: %w", err)
+	}
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+	if err != nil {
+		return err
+	}
+
+	feed_follows, err := s.Db.GetFeedFollowsForUser(s.Ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving feed follows: %w", err)
+	}
+	following := map[uuid.UUID]bool{}
+	for _, ff := range feed_follows {
+		following[ff.FeedID] = true
+	}
+
+	// Print a result for every feed, failures do not stop the import
+	counts := map[string]int{}
+	for _, sub := range doc.Subscriptions() {
+		result, err := importSubscription(s, user, sub, following)
+		if err != nil {
+			result = "error"
+			fmt.Printf("%v: %v (%v): %v\n", result, sub.Title, sub.XMLURL, err)
+		} else {
+			fmt.Printf("%v: %v (%v)\n", result, sub.Title, sub.XMLURL)
+		}
+		counts[result]++
+	}
+
+	fmt.Printf("Created and followed: %v\n", counts["created"])
+	fmt.Printf("Followed: %v\n", counts["followed"])
+	fmt.Printf("Already following: %v\n", counts["already following"])
+	fmt.Printf("Errors: %v\n", counts["error"])
+
+	return nil
+}
+
+// importSubscription creates the feed when it is missing and follows it
+// with the outline folder as category. It returns what was done.
+func importSubscription(s *State, user database.User, sub opml.Subscription, following map[uuid.UUID]bool) (string, error) {
+	result := "followed"
+	feed, err := s.Db.GetFeedByURL(s.Ctx, sub.XMLURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		name := sub.Title
+		if name == "" {
+			name = sub.XMLURL
+		}
+		feed, err = s.Db.CreateFeed(
+			s.Ctx,
+			database.CreateFeedParams{
+				ID:        uuid.New(),
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+				Name:      name,
+				Url:       sub.XMLURL,
+				UserID:    user.ID,
+			})
+		if err != nil {
+			return "", fmt.Errorf("error creating feed: %w", err)
+		}
+		result = "created"
+	} else if err != nil {
+		return "", fmt.Errorf("error getting feed by url: %w", err)
+	}
+
+	if following[feed.ID] {
+		return "already following", nil
+	}
+
+	_, err = s.Db.CreateFeedFollow(
+		s.Ctx,
+		database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			FeedID:    feed.ID,
+			Category:  sql.NullString{String: sub.Category, Valid: sub.Category != ""},
+		})
+	if err != nil {
+		return "", fmt.Errorf("error inserting feed follows: %w", err)
+	}
+	following[feed.ID] = true
+
+	return result, nil
+}
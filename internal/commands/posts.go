@@ -0,0 +1,265 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/crisp-coder/gator/internal/textdiff"
+	"github.com/google/uuid"
+)
+
+// getPost finds a post by the id printed by browse, or by its url.
+func getPost(s *State, id_or_url string) (database.Post, error) {
+	id, err := uuid.Parse(id_or_url)
+	if err == nil {
+		return s.Db.GetPostByID(s.Ctx, id)
+	}
+	return s.Db.GetPostByURL(s.Ctx, id_or_url)
+}
+
+func handleHistory(s *State, cmd Command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("missing post argument to history command")
+	}
+
+	post, err := getPost(s, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error retrieving post: %w", err)
+	}
+
+	revisions, err := s.Db.GetPostRevisions(s.Ctx, post.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving post revisions: %w", err)
+	}
+
+	// Each revision is the version that was replaced at its created_at, the
+	// post itself is the latest version.
+	type version struct {
+		title       string
+		description string
+		since       time.Time
+	}
+	versions := []version{}
+	since := post.CreatedAt
+	for _, revision := range revisions {
+		versions = append(versions, version{
+			title:       revision.Title,
+			description: revision.Description.String,
+			since:       since,
+		})
+		since = revision.CreatedAt
+	}
+	versions = append(versions, version{
+		title:       post.Title,
+		description: post.Description.String,
+		since:       since,
+	})
+
+	fmt.Printf("Link: %v\n", post.Url)
+	fmt.Printf("Revisions: %v\n", len(revisions))
+
+	first := versions[0]
+	fmt.Printf("\nVersion 1, %v\n", first.since)
+	fmt.Printf("Title: %v\n", first.title)
+	fmt.Printf("Description: %v\n", first.description)
+
+	for i := 1; i < len(versions); i++ {
+		previous, current := versions[i-1], versions[i]
+		fmt.Printf("\nVersion %v, %v\n", i+1, current.since)
+		if previous.title != current.title {
+			fmt.Printf("Title: %v\n", textdiff.Words(previous.title, current.title))
+		}
+		if previous.description != current.description {
+			fmt.Printf("Description: %v\n", textdiff.Words(previous.description, current.description))
+		}
+	}
+
+	return nil
+}
+
+func markPostRead(s *State, user database.User, post_id uuid.UUID) error {
+	return s.Db.MarkPostRead(
+		s.Ctx,
+		database.MarkPostReadParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			PostID:    post_id,
+		})
+}
+
+func handleRead(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("missing post argument to read command")
+	}
+
+	post, err := getPost(s, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error retrieving post: %w", err)
+	}
+
+	err = markPostRead(s, user, post.ID)
+	if err != nil {
+		return fmt.Errorf("error marking post read: %w", err)
+	}
+
+	fmt.Printf("Marked read: %v\n", post.Title)
+	return nil
+}
+
+func handleUnread(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("missing post argument to unread command")
+	}
+
+	post, err := getPost(s, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error retrieving post: %w", err)
+	}
+
+	err = s.Db.MarkPostUnread(
+		s.Ctx,
+		database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+		})
+	if err != nil {
+		return fmt.Errorf("error marking post unread: %w", err)
+	}
+
+	fmt.Printf("Marked unread: %v\n", post.Title)
+	return nil
+}
+
+func handleStar(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("missing post argument to star command")
+	}
+
+	post, err := getPost(s, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error retrieving post: %w", err)
+	}
+
+	err = s.Db.StarPost(
+		s.Ctx,
+		database.StarPostParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			UserID:    user.ID,
+			PostID:    post.ID,
+		})
+	if err != nil {
+		return fmt.Errorf("error starring post: %w", err)
+	}
+
+	fmt.Printf("Starred: %v\n", post.Title)
+	return nil
+}
+
+func handleUnstar(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("missing post argument to unstar command")
+	}
+
+	post, err := getPost(s, cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error retrieving post: %w", err)
+	}
+
+	err = s.Db.UnstarPost(
+		s.Ctx,
+		database.UnstarPostParams{
+			UserID: user.ID,
+			PostID: post.ID,
+		})
+	if err != nil {
+		return fmt.Errorf("error unstarring post: %w", err)
+	}
+
+	fmt.Printf("Unstarred: %v\n", post.Title)
+	return nil
+}
+
+func handleStarred(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) != 0 {
+		return fmt.Errorf("too many arguments to starred command")
+	}
+
+	posts, err := s.Db.GetStarredPostsForUser(s.Ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving starred posts: %w", err)
+	}
+
+	for _, post := range posts {
+		fmt.Printf("ID: %v\n", post.ID)
+		fmt.Printf("Feed: %v\n", post.Feedname)
+		fmt.Printf("Title: %v\n", post.Title)
+		fmt.Printf("Link: %v\n", post.Url)
+		fmt.Printf("Starred: %v\n", post.StarredAt)
+	}
+
+	return nil
+}
+
+func handlePrune(s *State, cmd Command) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("missing age argument to prune command")
+	}
+
+	age, err := time.ParseDuration(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("error parsing age: %w", err)
+	}
+
+	deleted, err := s.Db.DeletePostsPublishedBefore(s.Ctx, time.Now().Add(-age))
+	if err != nil {
+		return fmt.Errorf("error pruning posts: %w", err)
+	}
+
+	fmt.Printf("Deleted %v posts\n", deleted)
+	return nil
+}
+
+func handleSearch(s *State, cmd Command, user database.User) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	all := fs.Bool("all", false, "search posts from all feeds, not only followed feeds")
+	limit := fs.Int64("limit", 10, "maximum number of results")
+
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return fmt.Errorf("error parsing search flags: %w", err)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing query argument to search command")
+	}
+
+	results, err := s.Db.SearchPosts(
+		s.Ctx,
+		database.SearchPostsParams{
+			Query:    strings.Join(args, " "),
+			AllFeeds: *all,
+			UserID:   user.ID,
+			MaxPosts: *limit,
+		})
+	if err != nil {
+		return fmt.Errorf("error searching posts: %w", err)
+	}
+
+	for _, result := range results {
+		fmt.Printf("ID: %v\n", result.ID)
+		fmt.Printf("Feed: %v\n", result.Feedname)
+		fmt.Printf("Title: %v\n", result.Title)
+		fmt.Printf("Link: %v\n", result.Url)
+		fmt.Printf("PubDate: %v\n", result.PublishedAt)
+		fmt.Printf("Rank: %.3f\n", result.Rank)
+		fmt.Printf("Match: %v\n", result.Headline)
+	}
+
+	return nil
+}
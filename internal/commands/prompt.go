@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/crisp-coder/gator/internal/auth"
+	"github.com/crisp-coder/gator/internal/rss"
+	"golang.org/x/term"
+)
+
+// stdin is shared by the prompts so input buffered by one prompt is not lost
+// to the next, e.g. when passwords are piped in.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readLine reads one line of input from stdin without the line ending.
+// Input that ends without a line, e.g. </dev/null, reads as an empty line.
+func readLine() (string, error) {
+	line, err := stdin.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("error reading input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readPassword prompts for a password and reads it without echo. Input that
+// is not a terminal, e.g. a pipe in a script, is read as a plain line.
+func readPassword(prompt string) (string, error) {
+	fmt.Print(prompt)
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readLine()
+	}
+
+	password, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("error reading password: %w", err)
+	}
+	return string(password), nil
+}
+
+// readNewPassword prompts for a new password twice and returns its hash.
+// An empty password returns an invalid hash, leaving the account without
+// a password.
+func readNewPassword() (sql.NullString, error) {
+	password, err := readPassword("New password (empty for none): ")
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	if password == "" {
+		return sql.NullString{}, nil
+	}
+
+	confirm, err := readPassword("Repeat new password: ")
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	if password != confirm {
+		return sql.NullString{}, errors.New("passwords do not match")
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: hash, Valid: true}, nil
+}
+
+// discoverFeedURL returns the feed url for a url that may be a website.
+// When the site has several feeds the user picks one.
+func discoverFeedURL(s *State, page_url string) (string, error) {
+	candidates, err := rss.Discover(s.Ctx, page_url)
+	if err != nil {
+		return "", fmt.Errorf("error discovering feeds: %w", err)
+	}
+	if len(candidates) == 0 {
+		return "", errors.New("no feeds found for url")
+	}
+	if len(candidates) == 1 {
+		if candidates[0].URL != page_url {
+			fmt.Printf("Found feed: %v\n", candidates[0].URL)
+		}
+		return candidates[0].URL, nil
+	}
+
+	fmt.Printf("Found %v feeds:\n", len(candidates))
+	for i, candidate := range candidates {
+		fmt.Printf("%v) %v %v\n", i+1, candidate.Title, candidate.URL)
+	}
+	fmt.Printf("Choose a feed [1-%v]: ", len(candidates))
+
+	line, err := readLine()
+	if err != nil {
+		return "", err
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return "", fmt.Errorf("invalid choice: %q", line)
+	}
+	return candidates[choice-1].URL, nil
+}
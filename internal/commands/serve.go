@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/api"
+)
+
+// shutdownTimeout bounds the wait for in-flight requests on shutdown.
+const shutdownTimeout = 10 * time.Second
+
+func handleServe(s *State, cmd Command) error {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+
+	args, err := parseFlags(fs, cmd.Args)
+	if err != nil {
+		return fmt.Errorf("error parsing serve flags: %w", err)
+	}
+	if len(args) != 0 {
+		return fmt.Errorf("too many arguments to serve command")
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           api.NewServer(s.Db).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serve_err := make(chan error, 1)
+	go func() {
+		serve_err <- server.ListenAndServe()
+	}()
+	fmt.Printf("Serving api on %v\n", *addr)
+
+	select {
+	case err := <-serve_err:
+		return fmt.Errorf("error serving api: %w", err)
+	case <-s.Ctx.Done():
+	}
+
+	fmt.Printf("Stopping server.\n")
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(s.Ctx), shutdownTimeout)
+	defer cancel()
+	err = server.Shutdown(ctx)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error stopping server: %w", err)
+	}
+	return nil
+}
@@ -1,11 +1,17 @@
 package commands
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/crisp-coder/gator/internal/config"
 	"github.com/crisp-coder/gator/internal/database"
 )
 
 type State struct {
-	Db  *database.Queries
-	Cfg *config.Config
+	// Ctx is cancelled when the process receives SIGINT or SIGTERM.
+	Ctx  context.Context
+	Db   *database.Queries
+	Conn *sql.DB
+	Cfg  *config.Config
 }
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crisp-coder/gator/internal/auth"
+	"github.com/crisp-coder/gator/internal/database"
+	"github.com/google/uuid"
+)
+
+// tokenCommands are the subcommands of the token command.
+var tokenCommands = map[string]func(s *State, args []string, user database.User) error{
+	"create": handleTokenCreate,
+	"list":   handleTokenList,
+	"revoke": handleTokenRevoke,
+}
+
+func handleToken(s *State, cmd Command, user database.User) error {
+	if len(cmd.Args) < 1 {
+		return fmt.Errorf("usage: token <create|list|revoke>")
+	}
+
+	subcommand, ok := tokenCommands[cmd.Args[0]]
+	if !ok {
+		return fmt.Errorf("unknown token command: %v", cmd.Args[0])
+	}
+	return subcommand(s, cmd.Args[1:], user)
+}
+
+func handleTokenCreate(s *State, args []string, user database.User) error {
+	if len(args) != 1 {
+		return fmt.Errorf("missing name argument to token create command")
+	}
+
+	token, err := auth.CreateToken(s.Ctx, s.Db, user.ID, args[0])
+	if err != nil {
+		return fmt.Errorf("error creating token: %w", err)
+	}
+
+	fmt.Printf("Token: %v\n", token)
+	fmt.Printf("Save this token now, it cannot be shown again.\n")
+	return nil
+}
+
+func handleTokenList(s *State, args []string, user database.User) error {
+	if len(args) != 0 {
+		return fmt.Errorf("too many arguments to token list command")
+	}
+
+	tokens, err := s.Db.GetAPITokensForUser(s.Ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving tokens: %w", err)
+	}
+
+	for _, token := range tokens {
+		fmt.Printf("ID: %v\n", token.ID)
+		fmt.Printf("Name: %v\n", token.Name)
+		fmt.Printf("Created: %v\n", token.CreatedAt)
+		if token.LastUsedAt.Valid {
+			fmt.Printf("Last used: %v\n", token.LastUsedAt.Time)
+		} else {
+			fmt.Printf("Last used: never\n")
+		}
+	}
+
+	return nil
+}
+
+func handleTokenRevoke(s *State, args []string, user database.User) error {
+	if len(args) != 1 {
+		return fmt.Errorf("missing id argument to token revoke command")
+	}
+
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("error parsing token id: %w", err)
+	}
+
+	deleted, err := s.Db.DeleteAPIToken(
+		s.Ctx,
+		database.DeleteAPITokenParams{
+			ID:     id,
+			UserID: user.ID,
+		})
+	if err != nil {
+		return fmt.Errorf("error revoking token: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("token not found: %v", id)
+	}
+
+	fmt.Printf("Revoked token: %v\n", id)
+	return nil
+}
+
+// handleBootstrap issues the first api token of a user created before tokens
+// and passwords existed, and logs in as them. Each such user is bootstrapped
+// once, after that they log in with the token or a password set by passwd.
+func handleBootstrap(s *State, cmd Command) error {
+	if len(cmd.Args) != 1 {
+		return errors.New("usage: bootstrap <username>")
+	}
+
+	tx, err := s.Conn.BeginTx(s.Ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting bootstrap transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := s.Db.WithTx(tx)
+
+	user, err := queries.ClaimFirstToken(
+		s.Ctx,
+		database.ClaimFirstTokenParams{
+			Name:      cmd.Args[0],
+			UpdatedAt: time.Now(),
+		})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user %v does not need bootstrapping, use login", cmd.Args[0])
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving user: %w", err)
+	}
+
+	token, err := auth.CreateToken(s.Ctx, queries, user.ID, "cli")
+	if err != nil {
+		return fmt.Errorf("error creating token: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing token: %w", err)
+	}
+
+	err = s.Cfg.SetUser(user.Name, token)
+	if err != nil {
+		return fmt.Errorf("error handling bootstrap: %w", err)
+	}
+
+	fmt.Printf("User: %v has been set.\n", s.Cfg.Username)
+	fmt.Printf("Token: %v\n", token)
+	fmt.Printf("Save this token now, it cannot be shown again.\n")
+	return nil
+}
@@ -11,6 +11,8 @@ const CONFIG_FILE_NAME = ".gatorconfig.json"
 type Config struct {
 	Db_url   string
 	Username string
+	// Token is the api token of the logged in user, checked on every command.
+	Token string
 }
 
 func Read() (Config, error) {
@@ -33,8 +35,10 @@ func Read() (Config, error) {
 	return cfg, nil
 }
 
-func (c *Config) SetUser(username string) error {
+// SetUser saves the logged in user and their api token in the config file.
+func (c *Config) SetUser(username string, token string) error {
 	c.Username = username
+	c.Token = token
 	cfg_path, err := getConfigFilePath()
 	if err != nil {
 		return fmt.Errorf("error getting home directory: %w", err)
@@ -45,10 +49,15 @@ func (c *Config) SetUser(username string) error {
 		return fmt.Errorf("error marshalling config: %w", err)
 	}
 
-	err = os.WriteFile(cfg_path, bytes, 0666)
+	// The file holds the token, keep it private to the user
+	err = os.WriteFile(cfg_path, bytes, 0600)
 	if err != nil {
 		return fmt.Errorf("error writing config file: %w", err)
 	}
+	err = os.Chmod(cfg_path, 0600)
+	if err != nil {
+		return fmt.Errorf("error setting config file permissions: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,51 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A Cursor marks the last post of a browse page by its published_at and
+// id, encoded as an opaque token to pass back for the next page.
+type Cursor struct {
+	PublishedAt time.Time
+	ID          uuid.UUID
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%v", c.PublishedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func Decode(token string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	published_at, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	post_id, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	return Cursor{
+		PublishedAt: time.UnixMicro(published_at).UTC(),
+		ID:          post_id,
+	}, nil
+}
@@ -0,0 +1,136 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: api_tokens.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createAPIToken = `-- name: CreateAPIToken :one
+INSERT INTO api_tokens (id, created_at, user_id, name, token_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at, last_used_at, user_id, name, token_hash
+`
+
+type CreateAPITokenParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+	TokenHash string
+}
+
+func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
+	row := q.db.QueryRowContext(ctx, createAPIToken,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UserID,
+		arg.Name,
+		arg.TokenHash,
+	)
+	var i ApiToken
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.LastUsedAt,
+		&i.UserID,
+		&i.Name,
+		&i.TokenHash,
+	)
+	return i, err
+}
+
+const deleteAPIToken = `-- name: DeleteAPIToken :execrows
+DELETE
+FROM api_tokens
+WHERE id = $1 AND user_id = $2
+`
+
+type DeleteAPITokenParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) DeleteAPIToken(ctx context.Context, arg DeleteAPITokenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAPIToken, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAPITokensForUser = `-- name: GetAPITokensForUser :many
+SELECT id, created_at, last_used_at, user_id, name, token_hash
+FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at
+`
+
+func (q *Queries) GetAPITokensForUser(ctx context.Context, userID uuid.UUID) ([]ApiToken, error) {
+	rows, err := q.db.QueryContext(ctx, getAPITokensForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiToken
+	for rows.Next() {
+		var i ApiToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.LastUsedAt,
+			&i.UserID,
+			&i.Name,
+			&i.TokenHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserByAPIToken = `-- name: GetUserByAPIToken :one
+WITH used_token AS (
+    UPDATE api_tokens
+    SET last_used_at = $1::TIMESTAMP
+    WHERE token_hash = $2
+    RETURNING user_id
+)
+SELECT users.id, users.created_at, users.updated_at, users.name, users.hashed_password, users.fever_api_key_hash, users.needs_first_token
+FROM users
+INNER JOIN used_token ON users.id = used_token.user_id
+`
+
+type GetUserByAPITokenParams struct {
+	Now       time.Time
+	TokenHash string
+}
+
+// Returns the user owning the token and records when the token was used.
+func (q *Queries) GetUserByAPIToken(ctx context.Context, arg GetUserByAPITokenParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByAPIToken, arg.Now, arg.TokenHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.HashedPassword,
+		&i.FeverApiKeyHash,
+		&i.NeedsFirstToken,
+	)
+	return i, err
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,11 +15,11 @@ import (
 
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follows AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-    VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, created_at, updated_at, user_id, feed_id
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, category)
+    VALUES ($1, $2, $3, $4, $5, $6)
+    RETURNING id, created_at, updated_at, user_id, feed_id, category
 )
-SELECT inserted_feed_follows.id, inserted_feed_follows.created_at, inserted_feed_follows.updated_at, inserted_feed_follows.user_id, inserted_feed_follows.feed_id, users.name AS username, feeds.name AS feedname
+SELECT inserted_feed_follows.id, inserted_feed_follows.created_at, inserted_feed_follows.updated_at, inserted_feed_follows.user_id, inserted_feed_follows.feed_id, inserted_feed_follows.category, users.name AS username, feeds.name AS feedname
 FROM inserted_feed_follows
 INNER JOIN users ON inserted_feed_follows.user_id = users.id
 INNER JOIN feeds ON inserted_feed_follows.feed_id = feeds.id
@@ -30,6 +31,7 @@ type CreateFeedFollowParams struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Category  sql.NullString
 }
 
 type CreateFeedFollowRow struct {
@@ -38,6 +40,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Category  sql.NullString
 	Username  string
 	Feedname  string
 }
@@ -49,6 +52,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.FeedID,
+		arg.Category,
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
@@ -57,6 +61,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Category,
 		&i.Username,
 		&i.Feedname,
 	)
@@ -80,7 +85,7 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feeds.id as feed_id, feeds.name AS feedname, feeds.url AS url
+SELECT feeds.id as feed_id, feeds.name AS feedname, feeds.url AS url, feed_follows.category
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
@@ -91,6 +96,7 @@ type GetFeedFollowsForUserRow struct {
 	FeedID   uuid.UUID
 	Feedname string
 	Url      string
+	Category sql.NullString
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -102,7 +108,57 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	var items []GetFeedFollowsForUserRow
 	for rows.Next() {
 		var i GetFeedFollowsForUserRow
-		if err := rows.Scan(&i.FeedID, &i.Feedname, &i.Url); err != nil {
+		if err := rows.Scan(
+			&i.FeedID,
+			&i.Feedname,
+			&i.Url,
+			&i.Category,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeverFeedsForUser = `-- name: GetFeverFeedsForUser :many
+SELECT feeds.serial_id, feeds.name, feeds.url, feeds.last_fetched_at, feed_follows.category
+FROM feed_follows
+INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.serial_id
+`
+
+type GetFeverFeedsForUserRow struct {
+	SerialID      int64
+	Name          string
+	Url           string
+	LastFetchedAt sql.NullTime
+	Category      sql.NullString
+}
+
+func (q *Queries) GetFeverFeedsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeverFeedsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeverFeedsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeverFeedsForUserRow
+	for rows.Next() {
+		var i GetFeverFeedsForUserRow
+		if err := rows.Scan(
+			&i.SerialID,
+			&i.Name,
+			&i.Url,
+			&i.LastFetchedAt,
+			&i.Category,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
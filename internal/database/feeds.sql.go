@@ -11,12 +11,79 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const claimFeedsToFetch = `-- name: ClaimFeedsToFetch :many
+UPDATE feeds
+SET last_fetched_at = $1::TIMESTAMP,
+    updated_at = $1::TIMESTAMP,
+    next_fetch_at = $1::TIMESTAMP + make_interval(secs => fetch_interval_seconds)
+WHERE id IN (
+    SELECT id
+    FROM feeds
+    WHERE NOT paused
+    AND (next_fetch_at IS NULL OR next_fetch_at <= $1::TIMESTAMP)
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, paused, serial_id
+`
+
+type ClaimFeedsToFetchParams struct {
+	Now   time.Time
+	Batch int32
+}
+
+// Marks the feeds that are due as fetched and returns them. next_fetch_at
+// is pushed forward by the feed's interval so the feed is not claimed again
+// while it is being fetched. Rows locked by another aggregator are skipped,
+// so concurrent aggregators never claim the same feed.
+func (q *Queries) ClaimFeedsToFetch(ctx context.Context, arg ClaimFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, claimFeedsToFetch, arg.Now, arg.Batch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.FetchIntervalSeconds,
+			&i.NextFetchAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.Paused,
+			&i.SerialID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, created_at, updated_at, name, url, user_id)
 VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, paused, serial_id
 `
 
 type CreateFeedParams struct {
@@ -46,18 +113,69 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Paused,
+		&i.SerialID,
 	)
 	return i, err
 }
 
-const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at
+const deleteFeed = `-- name: DeleteFeed :execrows
+WITH deletable AS (
+    SELECT feeds.id
+    FROM feeds
+    WHERE feeds.id = $1 AND feeds.user_id = $2
+    AND NOT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id AND feed_follows.user_id <> feeds.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts
+        JOIN post_stars ON post_stars.post_id = posts.id
+        WHERE posts.feed_id = feeds.id
+    )
+), deleted_posts AS (
+    DELETE
+    FROM posts
+    WHERE posts.feed_id IN (SELECT id FROM deletable)
+)
+DELETE
 FROM feeds
-Where url = $1
+WHERE feeds.id IN (SELECT id FROM deletable)
 `
 
-func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
+type DeleteFeedParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+// Only the user that added a feed may delete it, and only while no other
+// user follows it and none of its posts are starred. Its follows and posts
+// are deleted with it.
+func (q *Queries) DeleteFeed(ctx context.Context, arg DeleteFeedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeed, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, paused, serial_id
+FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -67,19 +185,27 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Paused,
+		&i.SerialID,
 	)
 	return i, err
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-Select id, created_at, updated_at, name, url, user_id, last_fetched_at
+const getFeedByURL = `-- name: GetFeedByURL :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, paused, serial_id
 FROM feeds
-ORDER BY last_fetched_at DESC NULLS FIRST
-LIMIT 1
+Where url = $1
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
+func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByURL, url)
 	var i Feed
 	err := row.Scan(
 		&i.ID,
@@ -89,20 +215,35 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Paused,
+		&i.SerialID,
 	)
 	return i, err
 }
 
 const listFeeds = `-- name: ListFeeds :many
-SELECT feeds.name as name, feeds.url as url, users.name as username
+SELECT feeds.id as id, feeds.name as name, feeds.url as url, users.name as username,
+    feeds.consecutive_failures, feeds.last_error, feeds.last_success_at, feeds.paused
 FROM feeds
 LEFT JOIN users on users.id = feeds.user_id
 `
 
 type ListFeedsRow struct {
-	Name     string
-	Url      string
-	Username sql.NullString
+	ID                  uuid.UUID
+	Name                string
+	Url                 string
+	Username            sql.NullString
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	LastSuccessAt       sql.NullTime
+	Paused              bool
 }
 
 func (q *Queries) ListFeeds(ctx context.Context) ([]ListFeedsRow, error) {
@@ -114,7 +255,16 @@ func (q *Queries) ListFeeds(ctx context.Context) ([]ListFeedsRow, error) {
 	var items []ListFeedsRow
 	for rows.Next() {
 		var i ListFeedsRow
-		if err := rows.Scan(&i.Name, &i.Url, &i.Username); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.Username,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.Paused,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -132,7 +282,7 @@ const markFeedFetched = `-- name: MarkFeedFetched :one
 UPDATE feeds
 SET last_fetched_at = $2, updated_at = $2
 WHERE id = $1
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, paused, serial_id
 `
 
 type MarkFeedFetchedParams struct {
@@ -151,6 +301,136 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Paused,
+		&i.SerialID,
+	)
+	return i, err
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET consecutive_failures = $2,
+    last_error = $3,
+    next_fetch_at = $4,
+    paused = $5
+WHERE id = $1
+`
+
+type RecordFeedFailureParams struct {
+	ID                  uuid.UUID
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	NextFetchAt         sql.NullTime
+	Paused              bool
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure,
+		arg.ID,
+		arg.ConsecutiveFailures,
+		arg.LastError,
+		arg.NextFetchAt,
+		arg.Paused,
+	)
+	return err
+}
+
+const recordFeedSuccess = `-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET fetch_interval_seconds = $2,
+    next_fetch_at = $3,
+    last_success_at = $4,
+    consecutive_failures = 0,
+    last_error = NULL
+WHERE id = $1
+`
+
+type RecordFeedSuccessParams struct {
+	ID                   uuid.UUID
+	FetchIntervalSeconds int32
+	NextFetchAt          sql.NullTime
+	LastSuccessAt        sql.NullTime
+}
+
+func (q *Queries) RecordFeedSuccess(ctx context.Context, arg RecordFeedSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedSuccess,
+		arg.ID,
+		arg.FetchIntervalSeconds,
+		arg.NextFetchAt,
+		arg.LastSuccessAt,
+	)
+	return err
+}
+
+const releaseFeeds = `-- name: ReleaseFeeds :exec
+UPDATE feeds
+SET next_fetch_at = $1::TIMESTAMP, updated_at = $1::TIMESTAMP
+WHERE id = ANY($2::UUID[])
+`
+
+type ReleaseFeedsParams struct {
+	Now time.Time
+	Ids []uuid.UUID
+}
+
+// Makes claimed feeds that were not fetched due again, e.g. when agg stops
+// before reaching them.
+func (q *Queries) ReleaseFeeds(ctx context.Context, arg ReleaseFeedsParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeeds, arg.Now, pq.Array(arg.Ids))
+	return err
+}
+
+const resumeFeed = `-- name: ResumeFeed :one
+UPDATE feeds
+SET paused = FALSE, consecutive_failures = 0, next_fetch_at = NULL
+WHERE url = $1
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, fetch_interval_seconds, next_fetch_at, consecutive_failures, last_error, last_success_at, paused, serial_id
+`
+
+func (q *Queries) ResumeFeed(ctx context.Context, url string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, resumeFeed, url)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.FetchIntervalSeconds,
+		&i.NextFetchAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.Paused,
+		&i.SerialID,
 	)
 	return i, err
 }
+
+const setFeedCacheHeaders = `-- name: SetFeedCacheHeaders :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3
+WHERE id = $1
+`
+
+type SetFeedCacheHeadersParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) SetFeedCacheHeaders(ctx context.Context, arg SetFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedCacheHeaders, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
@@ -0,0 +1,89 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: post_reads.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const markFollowedPostsReadBefore = `-- name: MarkFollowedPostsReadBefore :exec
+INSERT INTO post_reads (id, created_at, updated_at, user_id, post_id)
+SELECT gen_random_uuid(), $1::TIMESTAMP, $1::TIMESTAMP, feed_follows.user_id, posts.id
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = $2
+AND posts.created_at < $3::TIMESTAMP
+AND ($4::BIGINT IS NULL OR feeds.serial_id = $4::BIGINT)
+AND ($5::TEXT IS NULL OR feed_follows.category = $5::TEXT)
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkFollowedPostsReadBeforeParams struct {
+	Now          time.Time
+	UserID       uuid.UUID
+	Before       time.Time
+	FeedSerialID sql.NullInt64
+	Category     sql.NullString
+}
+
+// Marks the posts saved before a time as read in the user's follows,
+// optionally only in one feed or one follow category.
+func (q *Queries) MarkFollowedPostsReadBefore(ctx context.Context, arg MarkFollowedPostsReadBeforeParams) error {
+	_, err := q.db.ExecContext(ctx, markFollowedPostsReadBefore,
+		arg.Now,
+		arg.UserID,
+		arg.Before,
+		arg.FeedSerialID,
+		arg.Category,
+	)
+	return err
+}
+
+const markPostRead = `-- name: MarkPostRead :exec
+INSERT INTO post_reads (id, created_at, updated_at, user_id, post_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, post_id) DO NOTHING
+`
+
+type MarkPostReadParams struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+}
+
+func (q *Queries) MarkPostRead(ctx context.Context, arg MarkPostReadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostRead,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.UserID,
+		arg.PostID,
+	)
+	return err
+}
+
+const markPostUnread = `-- name: MarkPostUnread :exec
+DELETE
+FROM post_reads
+WHERE user_id = $1 AND post_id = $2
+`
+
+type MarkPostUnreadParams struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+}
+
+func (q *Queries) MarkPostUnread(ctx context.Context, arg MarkPostUnreadParams) error {
+	_, err := q.db.ExecContext(ctx, markPostUnread, arg.UserID, arg.PostID)
+	return err
+}
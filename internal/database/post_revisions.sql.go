@@ -0,0 +1,82 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: post_revisions.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createPostRevision = `-- name: CreatePostRevision :exec
+INSERT INTO post_revisions (id, created_at, post_id, title, url, description)
+SELECT $1, $2, posts.id, posts.title, posts.url, posts.description
+FROM posts
+WHERE posts.feed_id = $3 AND posts.guid = $4
+AND (posts.title IS DISTINCT FROM $5 OR posts.description IS DISTINCT FROM $6)
+`
+
+type CreatePostRevisionParams struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	FeedID      uuid.UUID
+	Guid        string
+	Title       string
+	Description sql.NullString
+}
+
+// Saves the stored version of a post before it is overwritten by a changed
+// version with the same guid. Does nothing when the post is new or unchanged.
+func (q *Queries) CreatePostRevision(ctx context.Context, arg CreatePostRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createPostRevision,
+		arg.ID,
+		arg.CreatedAt,
+		arg.FeedID,
+		arg.Guid,
+		arg.Title,
+		arg.Description,
+	)
+	return err
+}
+
+const getPostRevisions = `-- name: GetPostRevisions :many
+SELECT id, created_at, post_id, title, url, description
+FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetPostRevisions(ctx context.Context, postID uuid.UUID) ([]PostRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getPostRevisions, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostRevision
+	for rows.Next() {
+		var i PostRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.PostID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -11,36 +11,238 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const countFeverItems = `-- name: CountFeverItems :one
+SELECT COUNT(*)
+FROM posts
+WHERE EXISTS (
+    SELECT 1
+    FROM feed_follows
+    WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+)
+OR EXISTS (
+    SELECT 1
+    FROM post_stars
+    WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+)
 `
 
-type CreatePostParams struct {
-	ID          uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+func (q *Queries) CountFeverItems(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFeverItems, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
+const countUnreadPostsByFeed = `-- name: CountUnreadPostsByFeed :many
+SELECT feeds.url AS feed_url, feed_follows.category,
+    COUNT(posts.id) AS unread,
+    MAX(posts.published_at)::TIMESTAMP AS newest_published_at
+FROM feed_follows
+JOIN feeds ON feed_follows.feed_id = feeds.id
+JOIN posts ON posts.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+)
+GROUP BY feeds.url, feed_follows.category
+`
+
+type CountUnreadPostsByFeedRow struct {
+	FeedUrl           string
+	Category          sql.NullString
+	Unread            int64
+	NewestPublishedAt time.Time
+}
+
+// Unread posts in each feed the user follows, with the newest unread post.
+func (q *Queries) CountUnreadPostsByFeed(ctx context.Context, userID uuid.UUID) ([]CountUnreadPostsByFeedRow, error) {
+	rows, err := q.db.QueryContext(ctx, countUnreadPostsByFeed, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountUnreadPostsByFeedRow
+	for rows.Next() {
+		var i CountUnreadPostsByFeedRow
+		if err := rows.Scan(
+			&i.FeedUrl,
+			&i.Category,
+			&i.Unread,
+			&i.NewestPublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deletePostsPublishedBefore = `-- name: DeletePostsPublishedBefore :execrows
+DELETE
+FROM posts
+WHERE published_at < $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_stars
+    WHERE post_stars.post_id = posts.id
+)
+`
+
+// Retention cleanup. Posts starred by any user are kept.
+func (q *Queries) DeletePostsPublishedBefore(ctx context.Context, publishedAt time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePostsPublishedBefore, publishedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeverItems = `-- name: GetFeverItems :many
+SELECT posts.id, posts.serial_id, feeds.serial_id AS feed_serial_id, posts.title, posts.url,
+    posts.description, posts.published_at,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = $1
+    )::BOOLEAN AS is_read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+    )::BOOLEAN AS is_starred
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE (
+    EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+    )
+    OR EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+    )
+)
+AND ($2::BIGINT IS NULL OR posts.serial_id > $2::BIGINT)
+AND ($3::BIGINT IS NULL OR posts.serial_id < $3::BIGINT)
+AND ($4::BIGINT[] IS NULL OR posts.serial_id = ANY($4::BIGINT[]))
+ORDER BY
+    CASE WHEN $5::BOOLEAN THEN posts.serial_id END DESC,
+    posts.serial_id ASC
+LIMIT $6::BIGINT
+`
+
+type GetFeverItemsParams struct {
+	UserID      uuid.UUID
+	SinceID     sql.NullInt64
+	MaxID       sql.NullInt64
+	WithIds     []int64
+	NewestFirst bool
+	MaxItems    int64
+}
+
+type GetFeverItemsRow struct {
+	ID           uuid.UUID
+	SerialID     int64
+	FeedSerialID int64
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  time.Time
+	IsRead       bool
+	IsStarred    bool
+}
+
+// Up to max_items posts for a Fever client, from the user's follows or
+// starred by the user. Items after since_id are returned oldest first, items
+// before max_id newest first. with_ids selects posts by serial id.
+func (q *Queries) GetFeverItems(ctx context.Context, arg GetFeverItemsParams) ([]GetFeverItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeverItems,
+		arg.UserID,
+		arg.SinceID,
+		arg.MaxID,
+		pq.Array(arg.WithIds),
+		arg.NewestFirst,
+		arg.MaxItems,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeverItemsRow
+	for rows.Next() {
+		var i GetFeverItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SerialID,
+			&i.FeedSerialID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, search_vector, serial_id
+FROM posts
+WHERE id = $1
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.SearchVector,
+		&i.SerialID,
+	)
+	return i, err
+}
+
+const getPostByURL = `-- name: GetPostByURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, guid, search_vector, serial_id
+FROM posts
+WHERE url = $1
+ORDER BY updated_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByURL, url)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -51,50 +253,103 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Guid,
+		&i.SearchVector,
+		&i.SerialID,
 	)
 	return i, err
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT posts.id, posts.created_at, posts.updated_at, title, posts.url, description, published_at, posts.feed_id, feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feeds.id, feeds.created_at, feeds.updated_at, name, feeds.url, feeds.user_id, last_fetched_at
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.serial_id,
+    feeds.name AS feedname,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    )::BOOLEAN AS is_read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = feed_follows.user_id
+    )::BOOLEAN AS is_starred
 FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON feed_follows.feed_id = feeds.id
-WHERE $1 = feed_follows.user_id
-ORDER BY published_at DESC
-LIMIT $2::BIGINT
+WHERE feed_follows.user_id = $1
+AND (
+    $2::BOOLEAN
+    OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    )
+)
+AND (
+    $3::TEXT IS NULL
+    OR feeds.url = $3::TEXT
+    OR feeds.name = $3::TEXT
+)
+AND ($4::TIMESTAMP IS NULL OR posts.published_at >= $4::TIMESTAMP)
+AND ($5::TIMESTAMP IS NULL OR posts.published_at < $5::TIMESTAMP)
+AND (
+    $6::TEXT IS NULL
+    OR strpos(lower(posts.title), lower($6::TEXT)) > 0
+    OR strpos(lower(posts.description), lower($6::TEXT)) > 0
+)
+AND (
+    $7::TIMESTAMP IS NULL
+    OR (posts.published_at, posts.id) < ($7::TIMESTAMP, $8::UUID)
+)
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT $9::BIGINT
 `
 
 type GetPostsForUserParams struct {
-	UserID  uuid.UUID
-	Column2 int64
+	UserID           uuid.UUID
+	IncludeRead      bool
+	Feed             sql.NullString
+	Since            sql.NullTime
+	Before           sql.NullTime
+	Grep             sql.NullString
+	AfterPublishedAt sql.NullTime
+	AfterID          uuid.NullUUID
+	MaxPosts         int64
 }
 
 type GetPostsForUserRow struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Title         string
-	Url           string
-	Description   sql.NullString
-	PublishedAt   time.Time
-	FeedID        uuid.UUID
-	ID_2          uuid.UUID
-	CreatedAt_2   time.Time
-	UpdatedAt_2   time.Time
-	UserID        uuid.UUID
-	FeedID_2      uuid.UUID
-	ID_3          uuid.UUID
-	CreatedAt_3   time.Time
-	UpdatedAt_3   time.Time
-	Name          string
-	Url_2         string
-	UserID_2      uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	FeedID      uuid.UUID
+	Guid        string
+	SerialID    int64
+	Feedname    string
+	IsRead      bool
+	IsStarred   bool
 }
 
+// Read posts are left out unless include_read is set. The nullable
+// arguments narrow the posts to a feed name or url, a published_at range,
+// and a case insensitive text in the title or description. Pages continue
+// after the (published_at, id) of the last post on the previous page.
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Column2)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.IncludeRead,
+		arg.Feed,
+		arg.Since,
+		arg.Before,
+		arg.Grep,
+		arg.AfterPublishedAt,
+		arg.AfterID,
+		arg.MaxPosts,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -111,18 +366,265 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
-			&i.ID_2,
-			&i.CreatedAt_2,
-			&i.UpdatedAt_2,
-			&i.UserID,
-			&i.FeedID_2,
-			&i.ID_3,
-			&i.CreatedAt_3,
-			&i.UpdatedAt_3,
-			&i.Name,
-			&i.Url_2,
-			&i.UserID_2,
-			&i.LastFetchedAt,
+			&i.Guid,
+			&i.SerialID,
+			&i.Feedname,
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReaderItems = `-- name: GetReaderItems :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.serial_id,
+    feeds.name AS feedname, feeds.url AS feed_url, feed_follows.category,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = $1
+    )::BOOLEAN AS is_read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+    )::BOOLEAN AS is_starred
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+WHERE (
+    feed_follows.id IS NOT NULL
+    OR EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+    )
+)
+AND (
+    NOT $2::BOOLEAN
+    OR EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+    )
+)
+AND (
+    NOT $3::BOOLEAN
+    OR EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = $1
+    )
+)
+AND (
+    NOT $4::BOOLEAN
+    OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = $1
+    )
+)
+AND ($5::TEXT IS NULL OR feeds.url = $5::TEXT)
+AND ($6::TEXT IS NULL OR feed_follows.category = $6::TEXT)
+AND ($7::TIMESTAMP IS NULL OR posts.published_at > $7::TIMESTAMP)
+AND ($8::TIMESTAMP IS NULL OR posts.published_at < $8::TIMESTAMP)
+AND (
+    $9::TIMESTAMP IS NULL
+    OR (
+        $10::BOOLEAN
+        AND (posts.published_at, posts.serial_id) > ($9::TIMESTAMP, $11::BIGINT)
+    )
+    OR (
+        NOT $10::BOOLEAN
+        AND (posts.published_at, posts.serial_id) < ($9::TIMESTAMP, $11::BIGINT)
+    )
+)
+ORDER BY
+    CASE WHEN $10::BOOLEAN THEN posts.published_at END ASC,
+    CASE WHEN $10::BOOLEAN THEN posts.serial_id END ASC,
+    posts.published_at DESC,
+    posts.serial_id DESC
+LIMIT $12::BIGINT
+`
+
+type GetReaderItemsParams struct {
+	UserID           uuid.UUID
+	StarredOnly      bool
+	ReadOnly         bool
+	ExcludeRead      bool
+	FeedUrl          sql.NullString
+	Category         sql.NullString
+	NewerThan        sql.NullTime
+	OlderThan        sql.NullTime
+	AfterPublishedAt sql.NullTime
+	OldestFirst      bool
+	AfterSerialID    sql.NullInt64
+	MaxItems         int64
+}
+
+type GetReaderItemsRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	FeedID      uuid.UUID
+	Guid        string
+	SerialID    int64
+	Feedname    string
+	FeedUrl     string
+	Category    sql.NullString
+	IsRead      bool
+	IsStarred   bool
+}
+
+// Posts for a Google Reader stream. Posts come from the user's follows, or
+// any feed when starred by the user. The flags and nullable arguments select
+// the stream, an optional label (follow category) or feed, and a
+// published_at range. Pages continue after the (published_at, serial_id) of
+// the last post on the previous page, in the direction of the order.
+func (q *Queries) GetReaderItems(ctx context.Context, arg GetReaderItemsParams) ([]GetReaderItemsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReaderItems,
+		arg.UserID,
+		arg.StarredOnly,
+		arg.ReadOnly,
+		arg.ExcludeRead,
+		arg.FeedUrl,
+		arg.Category,
+		arg.NewerThan,
+		arg.OlderThan,
+		arg.AfterPublishedAt,
+		arg.OldestFirst,
+		arg.AfterSerialID,
+		arg.MaxItems,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReaderItemsRow
+	for rows.Next() {
+		var i GetReaderItemsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Guid,
+			&i.SerialID,
+			&i.Feedname,
+			&i.FeedUrl,
+			&i.Category,
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReaderItemsByIDs = `-- name: GetReaderItemsByIDs :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.serial_id,
+    feeds.name AS feedname, feeds.url AS feed_url, feed_follows.category,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = $1
+    )::BOOLEAN AS is_read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+    )::BOOLEAN AS is_starred
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+WHERE posts.serial_id = ANY($2::BIGINT[])
+AND (
+    feed_follows.id IS NOT NULL
+    OR EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+    )
+)
+ORDER BY posts.published_at DESC, posts.serial_id DESC
+`
+
+type GetReaderItemsByIDsParams struct {
+	UserID    uuid.UUID
+	SerialIds []int64
+}
+
+type GetReaderItemsByIDsRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	FeedID      uuid.UUID
+	Guid        string
+	SerialID    int64
+	Feedname    string
+	FeedUrl     string
+	Category    sql.NullString
+	IsRead      bool
+	IsStarred   bool
+}
+
+// Posts by serial id, limited to the posts GetReaderItems may return.
+func (q *Queries) GetReaderItemsByIDs(ctx context.Context, arg GetReaderItemsByIDsParams) ([]GetReaderItemsByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReaderItemsByIDs, arg.UserID, pq.Array(arg.SerialIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReaderItemsByIDsRow
+	for rows.Next() {
+		var i GetReaderItemsByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.Guid,
+			&i.SerialID,
+			&i.Feedname,
+			&i.FeedUrl,
+			&i.Category,
+			&i.IsRead,
+			&i.IsStarred,
 		); err != nil {
 			return nil, err
 		}
@@ -136,3 +638,248 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const getStarredSerialIDsForUser = `-- name: GetStarredSerialIDsForUser :many
+SELECT posts.serial_id
+FROM posts
+JOIN post_stars ON post_stars.post_id = posts.id
+WHERE post_stars.user_id = $1
+ORDER BY posts.serial_id
+`
+
+func (q *Queries) GetStarredSerialIDsForUser(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getStarredSerialIDsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var serial_id int64
+		if err := rows.Scan(&serial_id); err != nil {
+			return nil, err
+		}
+		items = append(items, serial_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadSerialIDsForUser = `-- name: GetUnreadSerialIDsForUser :many
+SELECT posts.serial_id
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+)
+ORDER BY posts.serial_id
+`
+
+func (q *Queries) GetUnreadSerialIDsForUser(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreadSerialIDsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var serial_id int64
+		if err := rows.Scan(&serial_id); err != nil {
+			return nil, err
+		}
+		items = append(items, serial_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rekeyPost = `-- name: RekeyPost :exec
+UPDATE posts
+SET guid = $1
+WHERE posts.feed_id = $2
+AND posts.url = $3
+AND posts.guid = posts.url
+AND posts.guid <> $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM posts AS keyed
+    WHERE keyed.feed_id = $2 AND keyed.guid = $1
+)
+`
+
+type RekeyPostParams struct {
+	Guid   string
+	FeedID uuid.UUID
+	Url    string
+}
+
+// Moves a post still keyed by its url, as migrated posts are, to the guid
+// of its entry. Does nothing when the feed already has a post with the guid.
+func (q *Queries) RekeyPost(ctx context.Context, arg RekeyPostParams) error {
+	_, err := q.db.ExecContext(ctx, rekeyPost, arg.Guid, arg.FeedID, arg.Url)
+	return err
+}
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT posts.id, posts.title, posts.url, posts.published_at, feeds.name AS feedname,
+    ts_rank(posts.search_vector, websearch_to_tsquery('english', $1::TEXT))::REAL AS rank,
+    ts_headline(
+        'english',
+        posts.title || ' ' || coalesce(posts.description, ''),
+        websearch_to_tsquery('english', $1::TEXT),
+        'StartSel=*, StopSel=*, MaxFragments=2'
+    )::TEXT AS headline
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE posts.search_vector @@ websearch_to_tsquery('english', $1::TEXT)
+AND (
+    $2::BOOLEAN
+    OR EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $3
+    )
+)
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT $4::BIGINT
+`
+
+type SearchPostsParams struct {
+	Query    string
+	AllFeeds bool
+	UserID   uuid.UUID
+	MaxPosts int64
+}
+
+type SearchPostsRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt time.Time
+	Feedname    string
+	Rank        float32
+	Headline    string
+}
+
+// Ranks posts matching a web search style query. Only posts from feeds the
+// user follows are searched unless all_feeds is set. The headline is taken
+// from the title and description together, as both are searched.
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Query,
+		arg.AllFeeds,
+		arg.UserID,
+		arg.MaxPosts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.Feedname,
+			&i.Rank,
+			&i.Headline,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    updated_at = EXCLUDED.updated_at
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+OR posts.description IS DISTINCT FROM EXCLUDED.description
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, serial_id,
+    (xmax = 0)::BOOLEAN AS inserted
+`
+
+type UpsertPostParams struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	FeedID      uuid.UUID
+	Guid        string
+}
+
+type UpsertPostRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	FeedID      uuid.UUID
+	Guid        string
+	SerialID    int64
+	Inserted    bool
+}
+
+// Inserts the post, or updates the post with the same guid in the same feed
+// when its content changed. No row is returned when nothing changed.
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (UpsertPostRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.ID,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+		arg.Guid,
+	)
+	var i UpsertPostRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.SerialID,
+		&i.Inserted,
+	)
+	return i, err
+}
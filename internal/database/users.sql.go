@@ -7,22 +7,53 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const claimFirstToken = `-- name: ClaimFirstToken :one
+UPDATE users
+SET needs_first_token = false, updated_at = $2
+WHERE name = $1 AND needs_first_token
+RETURNING id, created_at, updated_at, name, hashed_password, fever_api_key_hash, needs_first_token
+`
+
+type ClaimFirstTokenParams struct {
+	Name      string
+	UpdatedAt time.Time
+}
+
+// Clears the flag of a user waiting for their first token. No row is
+// returned when the user is not flagged, so the token is only issued once.
+func (q *Queries) ClaimFirstToken(ctx context.Context, arg ClaimFirstTokenParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, claimFirstToken, arg.Name, arg.UpdatedAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.HashedPassword,
+		&i.FeverApiKeyHash,
+		&i.NeedsFirstToken,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
-VALUES ($1, $2, $3, $4)
-RETURNING id, created_at, updated_at, name
+INSERT INTO users (id, created_at, updated_at, name, hashed_password)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, created_at, updated_at, name, hashed_password, fever_api_key_hash, needs_first_token
 `
 
 type CreateUserParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Name           string
+	HashedPassword sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -31,6 +62,7 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Name,
+		arg.HashedPassword,
 	)
 	var i User
 	err := row.Scan(
@@ -38,12 +70,36 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.HashedPassword,
+		&i.FeverApiKeyHash,
+		&i.NeedsFirstToken,
+	)
+	return i, err
+}
+
+const getUserByFeverAPIKey = `-- name: GetUserByFeverAPIKey :one
+SELECT id, created_at, updated_at, name, hashed_password, fever_api_key_hash, needs_first_token
+FROM users
+WHERE fever_api_key_hash = $1
+`
+
+func (q *Queries) GetUserByFeverAPIKey(ctx context.Context, feverApiKeyHash sql.NullString) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByFeverAPIKey, feverApiKeyHash)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.HashedPassword,
+		&i.FeverApiKeyHash,
+		&i.NeedsFirstToken,
 	)
 	return i, err
 }
 
 const getUserByName = `-- name: GetUserByName :one
-SELECT id, created_at, updated_at, name
+SELECT id, created_at, updated_at, name, hashed_password, fever_api_key_hash, needs_first_token
 FROM users
 wHERE name = $1
 `
@@ -56,12 +112,15 @@ func (q *Queries) GetUserByName(ctx context.Context, name string) (User, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.Name,
+		&i.HashedPassword,
+		&i.FeverApiKeyHash,
+		&i.NeedsFirstToken,
 	)
 	return i, err
 }
 
 const listUsers = `-- name: ListUsers :many
-SELECT id, created_at, updated_at, name
+SELECT id, created_at, updated_at, name, hashed_password, fever_api_key_hash, needs_first_token
 FROM users
 `
 
@@ -79,6 +138,9 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.Name,
+			&i.HashedPassword,
+			&i.FeverApiKeyHash,
+			&i.NeedsFirstToken,
 		); err != nil {
 			return nil, err
 		}
@@ -101,3 +163,37 @@ func (q *Queries) Reset(ctx context.Context) error {
 	_, err := q.db.ExecContext(ctx, reset)
 	return err
 }
+
+const setUserFeverAPIKey = `-- name: SetUserFeverAPIKey :exec
+UPDATE users
+SET fever_api_key_hash = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetUserFeverAPIKeyParams struct {
+	ID              uuid.UUID
+	FeverApiKeyHash sql.NullString
+	UpdatedAt       time.Time
+}
+
+func (q *Queries) SetUserFeverAPIKey(ctx context.Context, arg SetUserFeverAPIKeyParams) error {
+	_, err := q.db.ExecContext(ctx, setUserFeverAPIKey, arg.ID, arg.FeverApiKeyHash, arg.UpdatedAt)
+	return err
+}
+
+const setUserPassword = `-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = $3
+WHERE id = $1
+`
+
+type SetUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword sql.NullString
+	UpdatedAt      time.Time
+}
+
+func (q *Queries) SetUserPassword(ctx context.Context, arg SetUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, setUserPassword, arg.ID, arg.HashedPassword, arg.UpdatedAt)
+	return err
+}
@@ -0,0 +1,15 @@
+package dates
+
+import (
+	"time"
+)
+
+// Parse parses a date given on the command line or in a query parameter,
+// either as a plain date in local time or as an RFC 3339 timestamp.
+func Parse(value string) (time.Time, error) {
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
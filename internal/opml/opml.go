@@ -0,0 +1,145 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is either a feed, when XMLURL is set, or a folder of outlines.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Subscription is a feed outline with the folders it was nested in.
+type Subscription struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	// Category is the folder names joined with "/", empty at the top level.
+	Category string
+}
+
+func Parse(r io.Reader) (*OPML, error) {
+	doc := OPML{}
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing opml: %w", err)
+	}
+	return &doc, nil
+}
+
+// Subscriptions flattens the nested outlines into the feeds they contain.
+func (doc *OPML) Subscriptions() []Subscription {
+	subscriptions := []Subscription{}
+	var walk func(outlines []Outline, folders []string)
+	walk = func(outlines []Outline, folders []string) {
+		for _, outline := range outlines {
+			name := strings.TrimSpace(outline.Title)
+			if name == "" {
+				name = strings.TrimSpace(outline.Text)
+			}
+
+			if outline.XMLURL != "" {
+				subscriptions = append(subscriptions, Subscription{
+					Title:    name,
+					XMLURL:   strings.TrimSpace(outline.XMLURL),
+					HTMLURL:  strings.TrimSpace(outline.HTMLURL),
+					Category: strings.Join(folders, "/"),
+				})
+			}
+
+			if len(outline.Outlines) > 0 {
+				walk(outline.Outlines, append(folders[:len(folders):len(folders)], name))
+			}
+		}
+	}
+	walk(doc.Body.Outlines, []string{})
+	return subscriptions
+}
+
+// New builds an OPML 2.0 document. Subscriptions with a category are nested
+// in folder outlines, one level for each "/" separated folder name.
+func New(title string, created time.Time, subscriptions []Subscription) *OPML {
+	doc := OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: created.Format(time.RFC1123Z),
+		},
+	}
+
+	for _, sub := range subscriptions {
+		outlines := &doc.Body.Outlines
+		if sub.Category != "" {
+			for _, folder := range strings.Split(sub.Category, "/") {
+				outlines = folderOutlines(outlines, folder)
+			}
+		}
+		*outlines = append(*outlines, Outline{
+			Text:    sub.Title,
+			Title:   sub.Title,
+			Type:    "rss",
+			XMLURL:  sub.XMLURL,
+			HTMLURL: sub.HTMLURL,
+		})
+	}
+
+	return &doc
+}
+
+// folderOutlines returns the children of the folder with the given name,
+// adding the folder when it does not exist yet.
+func folderOutlines(outlines *[]Outline, name string) *[]Outline {
+	for i := range *outlines {
+		outline := &(*outlines)[i]
+		if outline.XMLURL == "" && outline.Text == name {
+			return &outline.Outlines
+		}
+	}
+	*outlines = append(*outlines, Outline{Text: name, Title: name})
+	return &(*outlines)[len(*outlines)-1].Outlines
+}
+
+func (doc *OPML) Write(w io.Writer) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("error writing opml: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("error writing opml: %w", err)
+	}
+
+	_, err = io.WriteString(w, "\n")
+	if err != nil {
+		return fmt.Errorf("error writing opml: %w", err)
+	}
+	return nil
+}
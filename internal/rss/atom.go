@@ -0,0 +1,104 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type AtomFeed struct {
+	Title           string      `xml:"title"`
+	Subtitle        string      `xml:"subtitle"`
+	Link            []AtomLink  `xml:"link"`
+	UpdatePeriod    string      `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string      `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	Entry           []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Link      []AtomLink `xml:"link"`
+	Summary   AtomText   `xml:"summary"`
+	Content   AtomText   `xml:"content"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// AtomText holds an Atom text construct. For type="xhtml" the content is
+// markup, so it is kept as inner xml rather than character data.
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t AtomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+type atomParser struct{}
+
+func (atomParser) Sniff(doc *Document) bool {
+	return doc.Root.Space == atomNamespace && doc.Root.Local == "feed"
+}
+
+func (atomParser) Parse(doc *Document) (*Feed, error) {
+	atom := AtomFeed{}
+	err := xml.Unmarshal(doc.Body, &atom)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	feed := Feed{
+		Title:       atom.Title,
+		Link:        alternateLink(atom.Link),
+		Description: atom.Subtitle,
+		UpdateInterval: updateInterval(
+			"", atom.UpdatePeriod, atom.UpdateFrequency),
+	}
+
+	for _, entry := range atom.Entry {
+		// Prefer the full content, fall back to the summary.
+		description := entry.Content.String()
+		if description == "" {
+			description = entry.Summary.String()
+		}
+
+		// Published is optional in Atom, updated is required.
+		pub_date := entry.Published
+		if pub_date == "" {
+			pub_date = entry.Updated
+		}
+
+		feed.Entries = append(feed.Entries,
+			newEntry(entry.ID, entry.Title, alternateLink(entry.Link), description, pub_date))
+	}
+
+	return &feed, nil
+}
+
+// alternateLink returns the href of the rel="alternate" link. A link with
+// no rel attribute is an alternate link by definition.
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
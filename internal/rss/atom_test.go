@@ -0,0 +1,72 @@
+package rss
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseAtomFixture(t *testing.T, name string) *Feed {
+	t.Helper()
+	raw_body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	feed, err := Parse(NewDocument("application/atom+xml", raw_body))
+	if err != nil {
+		t.Fatalf("parsing %v: %v", name, err)
+	}
+	return feed
+}
+
+func TestAtom(t *testing.T) {
+	feed := parseAtomFixture(t, "atom.xml")
+
+	if feed.Title != "Atom Example" || feed.Description != "An Atom 1.0 document" {
+		t.Errorf("feed = %q %q", feed.Title, feed.Description)
+	}
+	if feed.Link != "https://example.org/" {
+		t.Errorf("feed link = %q, want the alternate link", feed.Link)
+	}
+	if feed.UpdateInterval != 12*time.Hour {
+		t.Errorf("update interval = %v, want 12h", feed.UpdateInterval)
+	}
+	if len(feed.Entries) != 3 {
+		t.Fatalf("got %v entries, want 3", len(feed.Entries))
+	}
+
+	xhtml := feed.Entries[0]
+	if xhtml.ID != "tag:example.org,2024:xhtml" {
+		t.Errorf("id = %q", xhtml.ID)
+	}
+	if xhtml.Link != "https://example.org/xhtml" {
+		t.Errorf("alternate link = %q", xhtml.Link)
+	}
+	if !strings.Contains(xhtml.Description, "<p>Hello <b>world</b></p>") {
+		t.Errorf("xhtml content not kept as markup: %q", xhtml.Description)
+	}
+	if !xhtml.Published.Equal(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("published = %v", xhtml.Published)
+	}
+
+	html := feed.Entries[1]
+	if html.Link != "https://example.org/html" {
+		t.Errorf("link without rel = %q", html.Link)
+	}
+	if html.Description != "<p>Escaped &amp; decoded</p>" {
+		t.Errorf("html summary fallback = %q", html.Description)
+	}
+	if !html.Published.Equal(time.Date(2024, 1, 14, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("updated fallback = %v", html.Published)
+	}
+
+	enclosure := feed.Entries[2]
+	if enclosure.Link != "https://example.org/episode.mp3" {
+		t.Errorf("first link fallback = %q", enclosure.Link)
+	}
+	if enclosure.Description != "Text summary" {
+		t.Errorf("text summary = %q", enclosure.Description)
+	}
+}
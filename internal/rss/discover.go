@@ -0,0 +1,149 @@
+package rss
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Candidate is a feed found for a website url.
+type Candidate struct {
+	URL   string
+	Title string
+}
+
+// feedLinkTypes are the link types announcing a feed in an html page.
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/feed+json": true,
+	"application/rdf+xml":   true,
+}
+
+// commonFeedPaths are tried when a page does not link to any feed.
+var commonFeedPaths = []string{
+	"/feed",
+	"/rss.xml",
+	"/atom.xml",
+	"/feed.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+// Discover finds the feeds for a url. When the url is a feed it is the only
+// candidate. Otherwise the page is searched for <link rel="alternate"> feed
+// links, and when there are none the common feed paths of the site are tried.
+func Discover(ctx context.Context, pageURL string) ([]Candidate, error) {
+	doc, final_url, err := fetchDocument(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	feed, err := Parse(doc)
+	if err == nil {
+		return []Candidate{{URL: pageURL, Title: feed.Title}}, nil
+	}
+
+	candidates := []Candidate{}
+	seen := map[string]bool{}
+	for _, link := range feedLinks(doc.Body) {
+		href, err := final_url.Parse(link.URL)
+		if err != nil || seen[href.String()] {
+			continue
+		}
+		seen[href.String()] = true
+		candidates = append(candidates, Candidate{URL: href.String(), Title: link.Title})
+	}
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+
+	for _, path := range commonFeedPaths {
+		feed_url := final_url.ResolveReference(&url.URL{Path: path}).String()
+		doc, _, err := fetchDocument(ctx, feed_url)
+		if err != nil {
+			continue
+		}
+		feed, err := Parse(doc)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, Candidate{URL: feed_url, Title: feed.Title})
+	}
+
+	return candidates, nil
+}
+
+// fetchDocument gets a url and returns the document and the url it was
+// served from after redirects, used to resolve relative links.
+func fetchDocument(ctx context.Context, pageURL string) (*Document, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w", err)
+	}
+
+	req.Header.Set("User-Agent", "gator")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("unexpected status fetching %v: %v", pageURL, res.Status)
+	}
+
+	raw_body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w", err)
+	}
+
+	return NewDocument(res.Header.Get("Content-Type"), raw_body), res.Request.URL, nil
+}
+
+// feedLinks returns the feed links in the head of an html page. The page is
+// read with the xml decoder in its lenient html mode, which is enough for
+// the link elements even when the rest of the page is not well formed.
+func feedLinks(raw_body []byte) []Candidate {
+	decoder := xml.NewDecoder(bytes.NewReader(raw_body))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	links := []Candidate{}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// End of the page, or markup the decoder cannot read
+			return links
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(start.Name.Local) {
+		case "body":
+			// Feed links belong in the head
+			return links
+		case "link":
+			attrs := map[string]string{}
+			for _, attr := range start.Attr {
+				attrs[strings.ToLower(attr.Name.Local)] = attr.Value
+			}
+			rels := strings.Fields(strings.ToLower(attrs["rel"]))
+			link_type := strings.ToLower(strings.TrimSpace(attrs["type"]))
+			if slices.Contains(rels, "alternate") && feedLinkTypes[link_type] && attrs["href"] != "" {
+				links = append(links, Candidate{URL: attrs["href"], Title: attrs["title"]})
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package rss
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feed is the format independent model every parser produces.
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Entries     []Entry
+	// UpdateInterval is how often the publisher says the feed changes, from
+	// <ttl> or the syndication module. Zero if the feed does not say.
+	UpdateInterval time.Duration
+	// Cache holds the validators of the response the feed was parsed from.
+	Cache CacheHeaders
+}
+
+type Entry struct {
+	// ID identifies the entry within its feed: the RSS guid, Atom id,
+	// JSON Feed id or rdf:about. Falls back to the link when missing.
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	// PubDate is the date as written in the feed, Published is the parsed
+	// value and is zero when PubDate could not be parsed.
+	PubDate   string
+	Published time.Time
+}
+
+func newEntry(id, title, link, description, pub_date string) Entry {
+	link = strings.TrimSpace(link)
+	id = strings.TrimSpace(id)
+	if id == "" {
+		id = link
+	}
+	pub_date = strings.TrimSpace(pub_date)
+	published, _ := parseDate(pub_date)
+	return Entry{
+		ID:          id,
+		Title:       title,
+		Link:        link,
+		Description: description,
+		PubDate:     pub_date,
+		Published:   published,
+	}
+}
+
+var dateLayouts = []string{
+	time.RFC1123Z, // "Mon, 02 Jan 2006 15:04:05 -0700"
+	time.RFC1123,  // "Mon, 02 Jan 2006 15:04:05 MST"
+	time.RFC822Z,  // "02 Jan 06 15:04 -0700"
+	time.RFC822,   // "02 Jan 06 15:04 MST"
+	time.RFC3339,  // "2006-01-02T15:04:05Z07:00"
+	time.DateOnly, // "2006-01-02", allowed by dc:date
+	// W3CDTF, used by dc:date, allows dates to the minute
+	"2006-01-02T15:04Z07:00",
+}
+
+// parseDate tries each of the date layouts used by the supported formats.
+func parseDate(value string) (time.Time, error) {
+	var parse_err error
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+		parse_err = err
+	}
+	return time.Time{}, fmt.Errorf("%w", parse_err)
+}
+
+var updatePeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// updateInterval converts an RSS <ttl> in minutes, or else the syndication
+// module's sy:updatePeriod divided by sy:updateFrequency, to a duration.
+func updateInterval(ttl, period, frequency string) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(ttl))
+	if err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+
+	update_period, ok := updatePeriods[strings.TrimSpace(period)]
+	if !ok {
+		return 0
+	}
+	update_frequency, err := strconv.Atoi(strings.TrimSpace(frequency))
+	if err != nil || update_frequency < 1 {
+		update_frequency = 1
+	}
+	return update_period / time.Duration(update_frequency)
+}
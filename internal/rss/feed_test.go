@@ -0,0 +1,34 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	values := []string{
+		"Mon, 15 Jan 2024 10:00:00 +0100",
+		"15 Jan 24 10:00 +0100",
+		"2024-01-15T10:00:00+01:00",
+		"2024-01-15T09:00:00Z",
+		"2024-01-15T10:00+01:00",
+		"2024-01-15T09:00Z",
+	}
+
+	for _, value := range values {
+		got, err := parseDate(value)
+		if err != nil {
+			t.Errorf("parseDate(%q): %v", value, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("parseDate(%q) = %v, want %v", value, got, want)
+		}
+	}
+
+	_, err := parseDate("yesterday")
+	if err == nil {
+		t.Errorf("parseDate accepted an invalid date")
+	}
+}
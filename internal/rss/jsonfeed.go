@@ -0,0 +1,109 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+)
+
+// JSONFeed covers versions 1.0 and 1.1 of https://jsonfeed.org. The item
+// fields used here are the same in both versions.
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            jsonFeedID `json:"id"`
+	URL           string     `json:"url"`
+	ExternalURL   string     `json:"external_url"`
+	Title         string     `json:"title"`
+	ContentHTML   string     `json:"content_html"`
+	ContentText   string     `json:"content_text"`
+	Summary       string     `json:"summary"`
+	DatePublished string     `json:"date_published"`
+	DateModified  string     `json:"date_modified"`
+}
+
+// jsonFeedID is a string, but the spec allows publishers to use numbers.
+type jsonFeedID string
+
+func (id *jsonFeedID) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*id = jsonFeedID(number.String())
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*id = jsonFeedID(text)
+	return nil
+}
+
+// jsonFeedVersions are the version urls of the supported JSON Feed versions.
+var jsonFeedVersions = map[string]bool{
+	"https://jsonfeed.org/version/1":   true,
+	"https://jsonfeed.org/version/1.1": true,
+}
+
+type jsonFeedParser struct{}
+
+// Sniff matches on the content type, or on the body starting with a json
+// object since many servers send JSON Feeds as text/plain.
+func (jsonFeedParser) Sniff(doc *Document) bool {
+	media_type, _, err := mime.ParseMediaType(doc.ContentType)
+	if err == nil && (media_type == "application/feed+json" || media_type == "application/json") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(doc.Body), []byte("{"))
+}
+
+func (jsonFeedParser) Parse(doc *Document) (*Feed, error) {
+	json_feed := JSONFeed{}
+	err := json.Unmarshal(doc.Body, &json_feed)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	if !jsonFeedVersions[json_feed.Version] {
+		return nil, fmt.Errorf("unsupported json feed version: %q", json_feed.Version)
+	}
+
+	feed := Feed{
+		Title:       json_feed.Title,
+		Link:        json_feed.HomePageURL,
+		Description: json_feed.Description,
+	}
+
+	for _, item := range json_feed.Items {
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+
+		description := item.ContentHTML
+		if description == "" {
+			description = item.ContentText
+		}
+		if description == "" {
+			description = item.Summary
+		}
+
+		pub_date := item.DatePublished
+		if pub_date == "" {
+			pub_date = item.DateModified
+		}
+
+		feed.Entries = append(feed.Entries,
+			newEntry(string(item.ID), item.Title, link, description, pub_date))
+	}
+
+	return &feed, nil
+}
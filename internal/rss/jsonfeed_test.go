@@ -0,0 +1,91 @@
+package rss
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func parseJSONFeedFixture(t *testing.T, name string) *Feed {
+	t.Helper()
+	raw_body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	feed, err := Parse(NewDocument("application/feed+json", raw_body))
+	if err != nil {
+		t.Fatalf("parsing %v: %v", name, err)
+	}
+	return feed
+}
+
+func TestJSONFeedVersion10(t *testing.T) {
+	feed := parseJSONFeedFixture(t, "jsonfeed-1.0.json")
+
+	if feed.Title != "Version One" || feed.Link != "https://example.org/" {
+		t.Errorf("feed = %q %q", feed.Title, feed.Link)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %v entries, want 2", len(feed.Entries))
+	}
+
+	numeric := feed.Entries[0]
+	if numeric.ID != "2" {
+		t.Errorf("numeric id = %q, want %q", numeric.ID, "2")
+	}
+	if numeric.Description != "<p>Second post</p>" {
+		t.Errorf("description = %q", numeric.Description)
+	}
+	if !numeric.Published.Equal(time.Date(2020, 1, 2, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("published = %v", numeric.Published)
+	}
+
+	text := feed.Entries[1]
+	if text.Description != "First post" {
+		t.Errorf("content_text fallback = %q", text.Description)
+	}
+	if !text.Published.Equal(time.Date(2020, 1, 1, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("published = %v", text.Published)
+	}
+}
+
+func TestJSONFeedVersion11(t *testing.T) {
+	feed := parseJSONFeedFixture(t, "jsonfeed-1.1.json")
+
+	if feed.Title != "Version One One" {
+		t.Errorf("title = %q", feed.Title)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("got %v entries, want 2", len(feed.Entries))
+	}
+
+	linked := feed.Entries[0]
+	if linked.Link != "https://elsewhere.example.net/story" {
+		t.Errorf("external_url fallback = %q", linked.Link)
+	}
+	if linked.Description != "Summary only" {
+		t.Errorf("summary fallback = %q", linked.Description)
+	}
+	if linked.Published.IsZero() {
+		t.Errorf("date_modified fallback not parsed from %q", linked.PubDate)
+	}
+
+	html := feed.Entries[1]
+	if html.ID != "no-link" || html.Link != "" {
+		t.Errorf("entry = %q %q", html.ID, html.Link)
+	}
+	if html.Description != "<p>html wins</p>" {
+		t.Errorf("content_html preferred = %q", html.Description)
+	}
+}
+
+func TestJSONFeedUnknownVersion(t *testing.T) {
+	for _, version := range []string{"https://jsonfeed.org/version/2", "1.0", ""} {
+		raw_body := []byte(`{"version": "` + version + `", "title": "x", "items": []}`)
+		_, err := Parse(NewDocument("application/feed+json", raw_body))
+		if err == nil {
+			t.Errorf("version %q was accepted", version)
+		}
+	}
+}
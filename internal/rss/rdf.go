@@ -0,0 +1,68 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+const (
+	rdfNamespace = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	dcNamespace  = "http://purl.org/dc/elements/1.1/"
+)
+
+// RDFFeed is an RSS 1.0 document. Unlike RSS 2.0 the items are siblings
+// of the channel element instead of children.
+type RDFFeed struct {
+	Channel struct {
+		Title           string `xml:"title"`
+		Link            string `xml:"link"`
+		Description     string `xml:"description"`
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+	Item []RDFItem `xml:"item"`
+}
+
+type RDFItem struct {
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+type rdfParser struct{}
+
+func (rdfParser) Sniff(doc *Document) bool {
+	return doc.Root.Space == rdfNamespace && doc.Root.Local == "RDF"
+}
+
+func (rdfParser) Parse(doc *Document) (*Feed, error) {
+	rdf := RDFFeed{}
+	err := xml.Unmarshal(doc.Body, &rdf)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	feed := Feed{
+		Title:       rdf.Channel.Title,
+		Link:        rdf.Channel.Link,
+		Description: rdf.Channel.Description,
+		UpdateInterval: updateInterval(
+			"", rdf.Channel.UpdatePeriod, rdf.Channel.UpdateFrequency),
+	}
+
+	for _, item := range rdf.Item {
+		// rdf:about is required and is usually the item link.
+		link := strings.TrimSpace(item.Link)
+		if link == "" {
+			link = item.About
+		}
+
+		feed.Entries = append(feed.Entries,
+			newEntry(item.About, item.Title, link, item.Description, item.Date))
+	}
+
+	return &feed, nil
+}
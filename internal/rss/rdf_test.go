@@ -0,0 +1,59 @@
+package rss
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func parseRDFFixture(t *testing.T, name string) *Feed {
+	t.Helper()
+	raw_body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+	feed, err := Parse(NewDocument("application/rdf+xml", raw_body))
+	if err != nil {
+		t.Fatalf("parsing %v: %v", name, err)
+	}
+	return feed
+}
+
+func TestRDF(t *testing.T) {
+	feed := parseRDFFixture(t, "rdf.xml")
+
+	if feed.Title != "RDF Example" || feed.Link != "https://example.org/" {
+		t.Errorf("feed = %q %q", feed.Title, feed.Link)
+	}
+	if feed.Description != "An RSS 1.0 document" {
+		t.Errorf("description = %q", feed.Description)
+	}
+	if feed.UpdateInterval != 30*time.Minute {
+		t.Errorf("update interval = %v, want 30m", feed.UpdateInterval)
+	}
+	if len(feed.Entries) != 3 {
+		t.Fatalf("got %v entries, want 3", len(feed.Entries))
+	}
+
+	both := feed.Entries[0]
+	if both.ID != "https://example.org/first" || both.Link != "https://example.org/first?utm_source=rss" {
+		t.Errorf("id and link = %q %q", both.ID, both.Link)
+	}
+	if !both.Published.Equal(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("minute precision dc:date = %v", both.Published)
+	}
+
+	about := feed.Entries[1]
+	if about.Link != "https://example.org/second" {
+		t.Errorf("rdf:about link fallback = %q", about.Link)
+	}
+	if !about.Published.Equal(time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date only dc:date = %v", about.Published)
+	}
+
+	link := feed.Entries[2]
+	if link.ID != "https://example.org/third" {
+		t.Errorf("link id fallback = %q", link.ID)
+	}
+}
@@ -0,0 +1,70 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+)
+
+// Document is a fetched response handed to the parsers for sniffing.
+type Document struct {
+	ContentType string
+	Body        []byte
+	// Root is the first element of an xml document, empty otherwise.
+	Root xml.Name
+}
+
+// Parser converts one feed format into the normalized Feed model.
+type Parser interface {
+	// Sniff reports whether the document is in the parser's format.
+	Sniff(doc *Document) bool
+	Parse(doc *Document) (*Feed, error)
+}
+
+var parsers = []Parser{
+	jsonFeedParser{},
+	atomParser{},
+	rdfParser{},
+	rssParser{},
+}
+
+// Register adds a parser for a new format. Parsers are tried in order and
+// the first whose Sniff matches parses the document.
+func Register(p Parser) {
+	parsers = append(parsers, p)
+}
+
+func NewDocument(content_type string, raw_body []byte) *Document {
+	doc := &Document{
+		ContentType: content_type,
+		Body:        raw_body,
+	}
+	if root, err := rootElement(raw_body); err == nil {
+		doc.Root = root
+	}
+	return doc
+}
+
+// Parse sniffs the document and parses it with the matching parser.
+func Parse(doc *Document) (*Feed, error) {
+	for _, p := range parsers {
+		if p.Sniff(doc) {
+			return p.Parse(doc)
+		}
+	}
+	return nil, errors.New("unsupported feed format")
+}
+
+// rootElement returns the name of the first element in the document.
+func rootElement(raw_body []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(raw_body))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
@@ -3,6 +3,7 @@ package rss
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"io"
@@ -11,10 +12,13 @@ import (
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title           string    `xml:"title"`
+		Link            string    `xml:"link"`
+		Description     string    `xml:"description"`
+		TTL             string    `xml:"ttl"`
+		UpdatePeriod    string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+		Item            []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
 
@@ -23,44 +27,100 @@ type RSSItem struct {
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
+	GUID        string `xml:"guid"`
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+type rssParser struct{}
+
+func (rssParser) Sniff(doc *Document) bool {
+	return doc.Root.Local == "rss"
+}
+
+func (rssParser) Parse(doc *Document) (*Feed, error) {
+	rss := RSSFeed{}
+	err := xml.Unmarshal(doc.Body, &rss)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	feed := Feed{
+		Title:       rss.Channel.Title,
+		Link:        rss.Channel.Link,
+		Description: rss.Channel.Description,
+		UpdateInterval: updateInterval(
+			rss.Channel.TTL, rss.Channel.UpdatePeriod, rss.Channel.UpdateFrequency),
+	}
+	for _, item := range rss.Channel.Item {
+		feed.Entries = append(feed.Entries,
+			newEntry(item.GUID, item.Title, item.Link, item.Description, item.PubDate))
+	}
+	return &feed, nil
+}
+
+// ErrNotModified is returned by FetchFeed when the provider answers the
+// conditional request with 304 Not Modified.
+var ErrNotModified = errors.New("feed not modified")
+
+// CacheHeaders are the validators from the previous response, sent back as
+// If-None-Match and If-Modified-Since. Empty values are not sent.
+type CacheHeaders struct {
+	ETag         string
+	LastModified string
+}
+
+func FetchFeed(ctx context.Context, feedURL string, cache CacheHeaders) (*Feed, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("%w", err)
 	}
 
 	req.Header.Set("User-Agent", "gator")
+	if cache.ETag != "" {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
+	if cache.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cache.LastModified)
+	}
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("%w", err)
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status fetching feed: %v", res.Status)
+	}
 
-	rss := RSSFeed{}
 	raw_body, err := io.ReadAll(res.Body)
 	if err != nil {
 		return nil, fmt.Errorf("%w", err)
 	}
 
-	err = xml.Unmarshal(raw_body, &rss)
+	feed, err := Parse(NewDocument(res.Header.Get("Content-Type"), raw_body))
 	if err != nil {
-		return nil, fmt.Errorf("%w", err)
+		return nil, err
+	}
 
+	feed.Cache = CacheHeaders{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
 	}
-	return &rss, nil
+	return feed, nil
 }
 
-func PrintFeed(rss_feed *RSSFeed) {
-	fmt.Println(html.UnescapeString(rss_feed.Channel.Title))
-	fmt.Println(html.UnescapeString(rss_feed.Channel.Link))
-	fmt.Println(html.UnescapeString(rss_feed.Channel.Description))
+func PrintFeed(feed *Feed) {
+	fmt.Println(html.UnescapeString(feed.Title))
+	fmt.Println(html.UnescapeString(feed.Link))
+	fmt.Println(html.UnescapeString(feed.Description))
 	indent := "  "
-	for _, item := range rss_feed.Channel.Item {
-		fmt.Println(indent + html.UnescapeString(item.Title))
-		fmt.Println(indent + html.UnescapeString(item.Link))
-		fmt.Println(indent + html.UnescapeString(item.Description))
-		fmt.Println(indent + html.UnescapeString(item.PubDate))
+	for _, entry := range feed.Entries {
+		fmt.Println(indent + html.UnescapeString(entry.Title))
+		fmt.Println(indent + html.UnescapeString(entry.Link))
+		fmt.Println(indent + html.UnescapeString(entry.Description))
+		fmt.Println(indent + html.UnescapeString(entry.PubDate))
 	}
 }
@@ -0,0 +1,143 @@
+package textdiff
+
+import (
+	"strings"
+)
+
+// Words compares two texts word by word and returns the new text with
+// removed words marked as [-words-] and added words marked as {+words+},
+// like git diff --word-diff. Whitespace is normalized to single spaces.
+func Words(old_text, new_text string) string {
+	old_words := strings.Fields(old_text)
+	new_words := strings.Fields(new_text)
+
+	out := []string{}
+	removed := []string{}
+	added := []string{}
+	flush := func() {
+		if len(removed) > 0 {
+			out = append(out, "[-"+strings.Join(removed, " ")+"-]")
+			removed = removed[:0]
+		}
+		if len(added) > 0 {
+			out = append(out, "{+"+strings.Join(added, " ")+"+}")
+			added = added[:0]
+		}
+	}
+
+	// Unchanged words at both ends need no alignment.
+	prefix := 0
+	for prefix < len(old_words) && prefix < len(new_words) && old_words[prefix] == new_words[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old_words)-prefix && suffix < len(new_words)-prefix &&
+		old_words[len(old_words)-1-suffix] == new_words[len(new_words)-1-suffix] {
+		suffix++
+	}
+
+	out = append(out, old_words[:prefix]...)
+	align(old_words[prefix:len(old_words)-suffix], new_words[prefix:len(new_words)-suffix], func(op byte, word string) {
+		switch op {
+		case '-':
+			removed = append(removed, word)
+		case '+':
+			added = append(added, word)
+		default:
+			flush()
+			out = append(out, word)
+		}
+	})
+	flush()
+	out = append(out, old_words[len(old_words)-suffix:]...)
+
+	return strings.Join(out, " ")
+}
+
+// align calls emit for each word of a longest common subsequence alignment
+// of old_words and new_words, with op '=' for kept, '-' for removed and '+'
+// for added words. It uses Hirschberg's algorithm, so memory grows with the
+// length of the texts rather than with their product.
+func align(old_words, new_words []string, emit func(op byte, word string)) {
+	switch {
+	case len(old_words) == 0:
+		for _, word := range new_words {
+			emit('+', word)
+		}
+		return
+	case len(new_words) == 0:
+		for _, word := range old_words {
+			emit('-', word)
+		}
+		return
+	case len(old_words) == 1:
+		for j, word := range new_words {
+			if word == old_words[0] {
+				for _, added := range new_words[:j] {
+					emit('+', added)
+				}
+				emit('=', word)
+				for _, added := range new_words[j+1:] {
+					emit('+', added)
+				}
+				return
+			}
+		}
+		emit('-', old_words[0])
+		for _, word := range new_words {
+			emit('+', word)
+		}
+		return
+	}
+
+	// Split the new words where the best alignments of both halves of the
+	// old words meet.
+	mid := len(old_words) / 2
+	head := prefixLengths(old_words[:mid], new_words)
+	tail := suffixLengths(old_words[mid:], new_words)
+	split := 0
+	for j := range new_words {
+		if head[j+1]+tail[j+1] > head[split]+tail[split] {
+			split = j + 1
+		}
+	}
+
+	align(old_words[:mid], new_words[:split], emit)
+	align(old_words[mid:], new_words[split:], emit)
+}
+
+// prefixLengths returns, for each j, the length of the longest common
+// subsequence of old_words and new_words[:j].
+func prefixLengths(old_words, new_words []string) []int {
+	prev := make([]int, len(new_words)+1)
+	cur := make([]int, len(new_words)+1)
+	for i := range old_words {
+		for j := range new_words {
+			if old_words[i] == new_words[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// suffixLengths returns, for each j, the length of the longest common
+// subsequence of old_words and new_words[j:].
+func suffixLengths(old_words, new_words []string) []int {
+	prev := make([]int, len(new_words)+1)
+	cur := make([]int, len(new_words)+1)
+	for i := len(old_words) - 1; i >= 0; i-- {
+		for j := len(new_words) - 1; j >= 0; j-- {
+			if old_words[i] == new_words[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
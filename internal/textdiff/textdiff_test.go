@@ -0,0 +1,46 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		old_text string
+		new_text string
+		want     string
+	}{
+		{"", "", ""},
+		{"same text", "same  text", "same text"},
+		{"", "all new", "{+all new+}"},
+		{"all gone", "", "[-all gone-]"},
+		{"the quick brown fox", "the slow brown fox", "the [-quick-] {+slow+} brown fox"},
+		{"a b c d e", "a c e f", "a [-b-] c [-d-] e {+f+}"},
+		{"one two three", "zero one two three four", "{+zero+} one two three {+four+}"},
+		{"x a b y", "x b a y", "x [-a-] b {+a+} y"},
+	}
+
+	for _, test := range tests {
+		got := Words(test.old_text, test.new_text)
+		if got != test.want {
+			t.Errorf("Words(%q, %q) = %q, want %q", test.old_text, test.new_text, got, test.want)
+		}
+	}
+}
+
+func TestWordsLongText(t *testing.T) {
+	old_words := make([]string, 5000)
+	new_words := make([]string, 5000)
+	for i := range old_words {
+		old_words[i] = "word"
+		new_words[i] = "word"
+	}
+	old_words[1000] = "before"
+	new_words[4000] = "after"
+
+	got := Words(strings.Join(old_words, " "), strings.Join(new_words, " "))
+	if !strings.Contains(got, "[-before-]") || !strings.Contains(got, "{+after+}") {
+		t.Errorf("changes missing from long diff")
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/crisp-coder/gator/internal/commands"
 	"github.com/crisp-coder/gator/internal/config"
@@ -12,6 +15,14 @@ import (
 )
 
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// The first signal cancels ctx for a graceful shutdown, restoring
+		// the default handling lets a second signal kill the process.
+		<-ctx.Done()
+		stop()
+	}()
 
 	cfg, err := config.Read()
 	if err != nil {
@@ -26,8 +37,10 @@ func main() {
 	dbQueries := database.New(db)
 
 	state := commands.State{
-		Db:  dbQueries,
-		Cfg: &cfg,
+		Ctx:  ctx,
+		Db:   dbQueries,
+		Conn: db,
+		Cfg:  &cfg,
 	}
 
 	cmds := commands.MakeCommands()
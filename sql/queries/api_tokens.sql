@@ -0,0 +1,27 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (id, created_at, user_id, name, token_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetUserByAPIToken :one
+-- Returns the user owning the token and records when the token was used.
+WITH used_token AS (
+    UPDATE api_tokens
+    SET last_used_at = sqlc.arg(now)::TIMESTAMP
+    WHERE token_hash = sqlc.arg(token_hash)
+    RETURNING user_id
+)
+SELECT users.*
+FROM users
+INNER JOIN used_token ON users.id = used_token.user_id;
+
+-- name: GetAPITokensForUser :many
+SELECT *
+FROM api_tokens
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteAPIToken :execrows
+DELETE
+FROM api_tokens
+WHERE id = $1 AND user_id = $2;
@@ -1,7 +1,7 @@
 -- name: CreateFeedFollow :one
 WITH inserted_feed_follows AS (
-    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
-    VALUES ($1, $2, $3, $4, $5)
+    INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id, category)
+    VALUES ($1, $2, $3, $4, $5, $6)
     RETURNING *
 )
 SELECT inserted_feed_follows.*, users.name AS username, feeds.name AS feedname
@@ -10,7 +10,7 @@ INNER JOIN users ON inserted_feed_follows.user_id = users.id
 INNER JOIN feeds ON inserted_feed_follows.feed_id = feeds.id;
 
 -- name: GetFeedFollowsForUser :many
-SELECT feeds.id as feed_id, feeds.name AS feedname, feeds.url AS url
+SELECT feeds.id as feed_id, feeds.name AS feedname, feeds.url AS url, feed_follows.category
 FROM feed_follows
 INNER JOIN feeds ON feed_follows.feed_id = feeds.id
 INNER JOIN users ON feed_follows.user_id = users.id
@@ -20,3 +20,10 @@ WHERE feed_follows.user_id = $1;
 DELETE
 FROM feed_follows
 WHERE user_id = $1 and feed_id = $2;
+
+-- name: GetFeverFeedsForUser :many
+SELECT feeds.serial_id, feeds.name, feeds.url, feeds.last_fetched_at, feed_follows.category
+FROM feed_follows
+INNER JOIN feeds ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.serial_id;
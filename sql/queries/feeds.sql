@@ -4,7 +4,8 @@ VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: ListFeeds :many
-SELECT feeds.name as name, feeds.url as url, users.name as username
+SELECT feeds.id as id, feeds.name as name, feeds.url as url, users.name as username,
+    feeds.consecutive_failures, feeds.last_error, feeds.last_success_at, feeds.paused
 FROM feeds
 LEFT JOIN users on users.id = feeds.user_id;
 
@@ -13,14 +14,96 @@ SELECT *
 FROM feeds
 Where url = $1;
 
+-- name: GetFeedByID :one
+SELECT *
+FROM feeds
+WHERE id = $1;
+
+-- name: DeleteFeed :execrows
+-- Only the user that added a feed may delete it, and only while no other
+-- user follows it and none of its posts are starred. Its follows and posts
+-- are deleted with it.
+WITH deletable AS (
+    SELECT feeds.id
+    FROM feeds
+    WHERE feeds.id = $1 AND feeds.user_id = $2
+    AND NOT EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = feeds.id AND feed_follows.user_id <> feeds.user_id
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts
+        JOIN post_stars ON post_stars.post_id = posts.id
+        WHERE posts.feed_id = feeds.id
+    )
+), deleted_posts AS (
+    DELETE
+    FROM posts
+    WHERE posts.feed_id IN (SELECT id FROM deletable)
+)
+DELETE
+FROM feeds
+WHERE feeds.id IN (SELECT id FROM deletable);
+
 -- name: MarkFeedFetched :one
 UPDATE feeds
 SET last_fetched_at = $2, updated_at = $2
 WHERE id = $1
 RETURNING *;
 
--- name: GetNextFeedToFetch :one
-Select *
-FROM feeds
-ORDER BY last_fetched_at DESC NULLS FIRST
-LIMIT 1;
+-- name: ClaimFeedsToFetch :many
+-- Marks the feeds that are due as fetched and returns them. next_fetch_at
+-- is pushed forward by the feed's interval so the feed is not claimed again
+-- while it is being fetched. Rows locked by another aggregator are skipped,
+-- so concurrent aggregators never claim the same feed.
+UPDATE feeds
+SET last_fetched_at = sqlc.arg(now)::TIMESTAMP,
+    updated_at = sqlc.arg(now)::TIMESTAMP,
+    next_fetch_at = sqlc.arg(now)::TIMESTAMP + make_interval(secs => fetch_interval_seconds)
+WHERE id IN (
+    SELECT id
+    FROM feeds
+    WHERE NOT paused
+    AND (next_fetch_at IS NULL OR next_fetch_at <= sqlc.arg(now)::TIMESTAMP)
+    ORDER BY next_fetch_at ASC NULLS FIRST
+    LIMIT sqlc.arg(batch)
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ReleaseFeeds :exec
+-- Makes claimed feeds that were not fetched due again, e.g. when agg stops
+-- before reaching them.
+UPDATE feeds
+SET next_fetch_at = sqlc.arg(now)::TIMESTAMP, updated_at = sqlc.arg(now)::TIMESTAMP
+WHERE id = ANY(sqlc.arg(ids)::UUID[]);
+
+-- name: SetFeedCacheHeaders :exec
+UPDATE feeds
+SET etag = $2, last_modified = $3
+WHERE id = $1;
+
+-- name: RecordFeedSuccess :exec
+UPDATE feeds
+SET fetch_interval_seconds = $2,
+    next_fetch_at = $3,
+    last_success_at = $4,
+    consecutive_failures = 0,
+    last_error = NULL
+WHERE id = $1;
+
+-- name: RecordFeedFailure :exec
+UPDATE feeds
+SET consecutive_failures = $2,
+    last_error = $3,
+    next_fetch_at = $4,
+    paused = $5
+WHERE id = $1;
+
+-- name: ResumeFeed :one
+UPDATE feeds
+SET paused = FALSE, consecutive_failures = 0, next_fetch_at = NULL
+WHERE url = $1
+RETURNING *;
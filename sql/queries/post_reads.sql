@@ -0,0 +1,23 @@
+-- name: MarkPostRead :exec
+INSERT INTO post_reads (id, created_at, updated_at, user_id, post_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: MarkPostUnread :exec
+DELETE
+FROM post_reads
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: MarkFollowedPostsReadBefore :exec
+-- Marks the posts saved before a time as read in the user's follows,
+-- optionally only in one feed or one follow category.
+INSERT INTO post_reads (id, created_at, updated_at, user_id, post_id)
+SELECT gen_random_uuid(), sqlc.arg(now)::TIMESTAMP, sqlc.arg(now)::TIMESTAMP, feed_follows.user_id, posts.id
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+JOIN feeds ON feeds.id = posts.feed_id
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND posts.created_at < sqlc.arg(before)::TIMESTAMP
+AND (sqlc.narg(feed_serial_id)::BIGINT IS NULL OR feeds.serial_id = sqlc.narg(feed_serial_id)::BIGINT)
+AND (sqlc.narg(category)::TEXT IS NULL OR feed_follows.category = sqlc.narg(category)::TEXT)
+ON CONFLICT (user_id, post_id) DO NOTHING;
@@ -0,0 +1,14 @@
+-- name: CreatePostRevision :exec
+-- Saves the stored version of a post before it is overwritten by a changed
+-- version with the same guid. Does nothing when the post is new or unchanged.
+INSERT INTO post_revisions (id, created_at, post_id, title, url, description)
+SELECT $1, $2, posts.id, posts.title, posts.url, posts.description
+FROM posts
+WHERE posts.feed_id = $3 AND posts.guid = $4
+AND (posts.title IS DISTINCT FROM $5 OR posts.description IS DISTINCT FROM $6);
+
+-- name: GetPostRevisions :many
+SELECT *
+FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at ASC;
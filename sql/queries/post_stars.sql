@@ -0,0 +1,19 @@
+-- name: StarPost :exec
+INSERT INTO post_stars (id, created_at, updated_at, user_id, post_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: UnstarPost :exec
+DELETE
+FROM post_stars
+WHERE user_id = $1 AND post_id = $2;
+
+-- name: GetStarredPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.serial_id,
+    feeds.name AS feedname, post_stars.created_at AS starred_at
+FROM post_stars
+JOIN posts ON post_stars.post_id = posts.id
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE post_stars.user_id = $1
+ORDER BY post_stars.created_at DESC;
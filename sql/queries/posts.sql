@@ -1,13 +1,319 @@
--- name: CreatePost :one
-INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING *;
+-- name: RekeyPost :exec
+-- Moves a post still keyed by its url, as migrated posts are, to the guid
+-- of its entry. Does nothing when the feed already has a post with the guid.
+UPDATE posts
+SET guid = sqlc.arg(guid)
+WHERE posts.feed_id = sqlc.arg(feed_id)
+AND posts.url = sqlc.arg(url)
+AND posts.guid = posts.url
+AND posts.guid <> sqlc.arg(guid)
+AND NOT EXISTS (
+    SELECT 1
+    FROM posts AS keyed
+    WHERE keyed.feed_id = sqlc.arg(feed_id) AND keyed.guid = sqlc.arg(guid)
+);
+
+-- name: UpsertPost :one
+-- Inserts the post, or updates the post with the same guid in the same feed
+-- when its content changed. No row is returned when nothing changed.
+INSERT INTO posts (id, created_at, updated_at, title, url, description, published_at, feed_id, guid)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    updated_at = EXCLUDED.updated_at
+WHERE posts.title IS DISTINCT FROM EXCLUDED.title
+OR posts.description IS DISTINCT FROM EXCLUDED.description
+RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id, guid, serial_id,
+    (xmax = 0)::BOOLEAN AS inserted;
 
 -- name: GetPostsForUser :many
-SELECT *
+-- Read posts are left out unless include_read is set. The nullable
+-- arguments narrow the posts to a feed name or url, a published_at range,
+-- and a case insensitive text in the title or description. Pages continue
+-- after the (published_at, id) of the last post on the previous page.
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.serial_id,
+    feeds.name AS feedname,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    )::BOOLEAN AS is_read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = feed_follows.user_id
+    )::BOOLEAN AS is_starred
 FROM posts
 JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
 JOIN feeds ON feed_follows.feed_id = feeds.id
-WHERE $1 = feed_follows.user_id
-ORDER BY published_at DESC
-LIMIT $2::BIGINT;
+WHERE feed_follows.user_id = sqlc.arg(user_id)
+AND (
+    sqlc.arg(include_read)::BOOLEAN
+    OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+    )
+)
+AND (
+    sqlc.narg(feed)::TEXT IS NULL
+    OR feeds.url = sqlc.narg(feed)::TEXT
+    OR feeds.name = sqlc.narg(feed)::TEXT
+)
+AND (sqlc.narg(since)::TIMESTAMP IS NULL OR posts.published_at >= sqlc.narg(since)::TIMESTAMP)
+AND (sqlc.narg(before)::TIMESTAMP IS NULL OR posts.published_at < sqlc.narg(before)::TIMESTAMP)
+AND (
+    sqlc.narg(grep)::TEXT IS NULL
+    OR strpos(lower(posts.title), lower(sqlc.narg(grep)::TEXT)) > 0
+    OR strpos(lower(posts.description), lower(sqlc.narg(grep)::TEXT)) > 0
+)
+AND (
+    sqlc.narg(after_published_at)::TIMESTAMP IS NULL
+    OR (posts.published_at, posts.id) < (sqlc.narg(after_published_at)::TIMESTAMP, sqlc.narg(after_id)::UUID)
+)
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg(max_posts)::BIGINT;
+
+-- name: GetPostByID :one
+SELECT *
+FROM posts
+WHERE id = $1;
+
+-- name: GetPostByURL :one
+SELECT *
+FROM posts
+WHERE url = $1
+ORDER BY updated_at DESC
+LIMIT 1;
+
+-- name: DeletePostsPublishedBefore :execrows
+-- Retention cleanup. Posts starred by any user are kept.
+DELETE
+FROM posts
+WHERE published_at < $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_stars
+    WHERE post_stars.post_id = posts.id
+);
+
+-- name: SearchPosts :many
+-- Ranks posts matching a web search style query. Only posts from feeds the
+-- user follows are searched unless all_feeds is set. The headline is taken
+-- from the title and description together, as both are searched.
+SELECT posts.id, posts.title, posts.url, posts.published_at, feeds.name AS feedname,
+    ts_rank(posts.search_vector, websearch_to_tsquery('english', sqlc.arg(query)::TEXT))::REAL AS rank,
+    ts_headline(
+        'english',
+        posts.title || ' ' || coalesce(posts.description, ''),
+        websearch_to_tsquery('english', sqlc.arg(query)::TEXT),
+        'StartSel=*, StopSel=*, MaxFragments=2'
+    )::TEXT AS headline
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE posts.search_vector @@ websearch_to_tsquery('english', sqlc.arg(query)::TEXT)
+AND (
+    sqlc.arg(all_feeds)::BOOLEAN
+    OR EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg(user_id)
+    )
+)
+ORDER BY rank DESC, posts.published_at DESC
+LIMIT sqlc.arg(max_posts)::BIGINT;
+
+-- name: GetReaderItems :many
+-- Posts for a Google Reader stream. Posts come from the user's follows, or
+-- any feed when starred by the user. The flags and nullable arguments select
+-- the stream, an optional label (follow category) or feed, and a
+-- published_at range. Pages continue after the (published_at, serial_id) of
+-- the last post on the previous page, in the direction of the order.
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.serial_id,
+    feeds.name AS feedname, feeds.url AS feed_url, feed_follows.category,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg(user_id)
+    )::BOOLEAN AS is_read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    )::BOOLEAN AS is_starred
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg(user_id)
+WHERE (
+    feed_follows.id IS NOT NULL
+    OR EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    )
+)
+AND (
+    NOT sqlc.arg(starred_only)::BOOLEAN
+    OR EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    )
+)
+AND (
+    NOT sqlc.arg(read_only)::BOOLEAN
+    OR EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg(user_id)
+    )
+)
+AND (
+    NOT sqlc.arg(exclude_read)::BOOLEAN
+    OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg(user_id)
+    )
+)
+AND (sqlc.narg(feed_url)::TEXT IS NULL OR feeds.url = sqlc.narg(feed_url)::TEXT)
+AND (sqlc.narg(category)::TEXT IS NULL OR feed_follows.category = sqlc.narg(category)::TEXT)
+AND (sqlc.narg(newer_than)::TIMESTAMP IS NULL OR posts.published_at > sqlc.narg(newer_than)::TIMESTAMP)
+AND (sqlc.narg(older_than)::TIMESTAMP IS NULL OR posts.published_at < sqlc.narg(older_than)::TIMESTAMP)
+AND (
+    sqlc.narg(after_published_at)::TIMESTAMP IS NULL
+    OR (
+        sqlc.arg(oldest_first)::BOOLEAN
+        AND (posts.published_at, posts.serial_id) > (sqlc.narg(after_published_at)::TIMESTAMP, sqlc.narg(after_serial_id)::BIGINT)
+    )
+    OR (
+        NOT sqlc.arg(oldest_first)::BOOLEAN
+        AND (posts.published_at, posts.serial_id) < (sqlc.narg(after_published_at)::TIMESTAMP, sqlc.narg(after_serial_id)::BIGINT)
+    )
+)
+ORDER BY
+    CASE WHEN sqlc.arg(oldest_first)::BOOLEAN THEN posts.published_at END ASC,
+    CASE WHEN sqlc.arg(oldest_first)::BOOLEAN THEN posts.serial_id END ASC,
+    posts.published_at DESC,
+    posts.serial_id DESC
+LIMIT sqlc.arg(max_items)::BIGINT;
+
+-- name: GetReaderItemsByIDs :many
+-- Posts by serial id, limited to the posts GetReaderItems may return.
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description,
+    posts.published_at, posts.feed_id, posts.guid, posts.serial_id,
+    feeds.name AS feedname, feeds.url AS feed_url, feed_follows.category,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg(user_id)
+    )::BOOLEAN AS is_read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    )::BOOLEAN AS is_starred
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN feed_follows ON feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg(user_id)
+WHERE posts.serial_id = ANY(sqlc.arg(serial_ids)::BIGINT[])
+AND (
+    feed_follows.id IS NOT NULL
+    OR EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    )
+)
+ORDER BY posts.published_at DESC, posts.serial_id DESC;
+
+-- name: CountUnreadPostsByFeed :many
+-- Unread posts in each feed the user follows, with the newest unread post.
+SELECT feeds.url AS feed_url, feed_follows.category,
+    COUNT(posts.id) AS unread,
+    MAX(posts.published_at)::TIMESTAMP AS newest_published_at
+FROM feed_follows
+JOIN feeds ON feed_follows.feed_id = feeds.id
+JOIN posts ON posts.feed_id = feeds.id
+WHERE feed_follows.user_id = $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+)
+GROUP BY feeds.url, feed_follows.category;
+
+-- name: GetFeverItems :many
+-- Up to max_items posts for a Fever client, from the user's follows or
+-- starred by the user. Items after since_id are returned oldest first, items
+-- before max_id newest first. with_ids selects posts by serial id.
+SELECT posts.id, posts.serial_id, feeds.serial_id AS feed_serial_id, posts.title, posts.url,
+    posts.description, posts.published_at,
+    EXISTS (
+        SELECT 1
+        FROM post_reads
+        WHERE post_reads.post_id = posts.id AND post_reads.user_id = sqlc.arg(user_id)
+    )::BOOLEAN AS is_read,
+    EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    )::BOOLEAN AS is_starred
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id
+WHERE (
+    EXISTS (
+        SELECT 1
+        FROM feed_follows
+        WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = sqlc.arg(user_id)
+    )
+    OR EXISTS (
+        SELECT 1
+        FROM post_stars
+        WHERE post_stars.post_id = posts.id AND post_stars.user_id = sqlc.arg(user_id)
+    )
+)
+AND (sqlc.narg(since_id)::BIGINT IS NULL OR posts.serial_id > sqlc.narg(since_id)::BIGINT)
+AND (sqlc.narg(max_id)::BIGINT IS NULL OR posts.serial_id < sqlc.narg(max_id)::BIGINT)
+AND (sqlc.narg(with_ids)::BIGINT[] IS NULL OR posts.serial_id = ANY(sqlc.narg(with_ids)::BIGINT[]))
+ORDER BY
+    CASE WHEN sqlc.arg(newest_first)::BOOLEAN THEN posts.serial_id END DESC,
+    posts.serial_id ASC
+LIMIT sqlc.arg(max_items)::BIGINT;
+
+-- name: CountFeverItems :one
+SELECT COUNT(*)
+FROM posts
+WHERE EXISTS (
+    SELECT 1
+    FROM feed_follows
+    WHERE feed_follows.feed_id = posts.feed_id AND feed_follows.user_id = $1
+)
+OR EXISTS (
+    SELECT 1
+    FROM post_stars
+    WHERE post_stars.post_id = posts.id AND post_stars.user_id = $1
+);
+
+-- name: GetUnreadSerialIDsForUser :many
+SELECT posts.serial_id
+FROM posts
+JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE feed_follows.user_id = $1
+AND NOT EXISTS (
+    SELECT 1
+    FROM post_reads
+    WHERE post_reads.post_id = posts.id AND post_reads.user_id = feed_follows.user_id
+)
+ORDER BY posts.serial_id;
+
+-- name: GetStarredSerialIDsForUser :many
+SELECT posts.serial_id
+FROM posts
+JOIN post_stars ON post_stars.post_id = posts.id
+WHERE post_stars.user_id = $1
+ORDER BY posts.serial_id;
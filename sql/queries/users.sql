@@ -1,6 +1,6 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
-VALUES ($1, $2, $3, $4)
+INSERT INTO users (id, created_at, updated_at, name, hashed_password)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetUserByName :one
@@ -14,3 +14,26 @@ DELETE FROM users;
 -- name: ListUsers :many
 SELECT *
 FROM users;
+
+-- name: SetUserPassword :exec
+UPDATE users
+SET hashed_password = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: SetUserFeverAPIKey :exec
+UPDATE users
+SET fever_api_key_hash = $2, updated_at = $3
+WHERE id = $1;
+
+-- name: GetUserByFeverAPIKey :one
+SELECT *
+FROM users
+WHERE fever_api_key_hash = $1;
+
+-- name: ClaimFirstToken :one
+-- Clears the flag of a user waiting for their first token. No row is
+-- returned when the user is not flagged, so the token is only issued once.
+UPDATE users
+SET needs_first_token = false, updated_at = $2
+WHERE name = $1 AND needs_first_token
+RETURNING *;
@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN guid TEXT;
+
+-- Existing posts are keyed by their url until they are fetched again. The
+-- scraper then moves them to the entry's own guid or id with RekeyPost, so
+-- feeds whose guids differ from their links do not get duplicate posts.
+UPDATE posts
+SET guid = url;
+
+ALTER TABLE posts
+ALTER COLUMN guid SET NOT NULL,
+DROP CONSTRAINT posts_url_key,
+ADD CONSTRAINT uniq_feed_guid UNIQUE (feed_id, guid);
+
+-- +goose Down
+-- Posts may share a url once keyed by guid, keep the oldest of each.
+DELETE FROM posts
+USING posts AS older
+WHERE posts.url = older.url
+AND (posts.created_at, posts.id) > (older.created_at, older.id);
+
+ALTER TABLE posts
+DROP CONSTRAINT uniq_feed_guid,
+ADD CONSTRAINT posts_url_key UNIQUE (url),
+DROP COLUMN guid;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE post_stars (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    post_id UUID NOT NULL,
+    CONSTRAINT fk_user_id FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_post_id FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT uniq_user_post_star UNIQUE (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE post_stars;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Numeric ids for apis whose clients expect integer item ids
+ALTER TABLE posts
+ADD COLUMN serial_id BIGSERIAL NOT NULL,
+ADD CONSTRAINT uniq_post_serial_id UNIQUE (serial_id);
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN serial_id;
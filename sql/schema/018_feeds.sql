@@ -0,0 +1,9 @@
+-- +goose Up
+-- Numeric ids for apis whose clients expect integer feed ids
+ALTER TABLE feeds
+ADD COLUMN serial_id BIGSERIAL NOT NULL,
+ADD CONSTRAINT uniq_feed_serial_id UNIQUE (serial_id);
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN serial_id;
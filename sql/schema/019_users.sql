@@ -0,0 +1,9 @@
+-- +goose Up
+-- Hash of the key Fever clients send to authenticate, the md5 of
+-- "username:password". Like api tokens only the hash is stored.
+ALTER TABLE users
+ADD COLUMN fever_api_key_hash TEXT UNIQUE;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN fever_api_key_hash;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Users from before api tokens and passwords existed have neither. They are
+-- flagged so bootstrap can issue their first token, once.
+ALTER TABLE users
+ADD COLUMN needs_first_token BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE users
+SET needs_first_token = true
+WHERE hashed_password IS NULL
+AND NOT EXISTS (
+    SELECT 1
+    FROM api_tokens
+    WHERE api_tokens.user_id = users.id
+);
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN needs_first_token;